@@ -0,0 +1,227 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/bot"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/sender"
+	"Bug_tracking_bot/internal/silence"
+	"Bug_tracking_bot/internal/subscription"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// recentAlertsSize сколько алертов помнить: /last показывает немного, но кнопки
+// «Принял» и «Исходная строка» должны работать и для алертов постарше
+const recentAlertsSize = 500
+
+// recentAlerts кольцевой буфер последних алертов для /last, /status и кнопок под алертами
+type recentAlerts struct {
+	entries []recentAlert
+	next    int
+}
+
+type recentAlert struct {
+	id string // sender.AlertID, по нему кнопки находят алерт
+	bot.Alert
+}
+
+func (r *recentAlerts) add(e log_processing.LogEntry) {
+	a := recentAlert{id: sender.AlertID(e), Alert: bot.Alert{Entry: e}}
+	if len(r.entries) < recentAlertsSize {
+		r.entries = append(r.entries, a)
+		return
+	}
+	r.entries[r.next] = a
+	r.next = (r.next + 1) % recentAlertsSize
+}
+
+// last до n последних алертов, новые первыми
+func (r *recentAlerts) last(n int) []bot.Alert {
+	n = min(n, len(r.entries))
+	out := make([]bot.Alert, 0, n)
+	for i := 0; i < n; i++ {
+		idx := (r.next - 1 - i + 2*len(r.entries)) % len(r.entries)
+		out = append(out, r.entries[idx].Alert)
+	}
+	return out
+}
+
+// find алерт по sender.AlertID
+func (r *recentAlerts) find(id string) *recentAlert {
+	for i := range r.entries {
+		if r.entries[i].id == id {
+			return &r.entries[i]
+		}
+	}
+	return nil
+}
+
+// botBackend отдаёт боту состояние Runtime. Бот работает в своей горутине, а Runtime
+// не защищён мьютексом, поэтому каждый вызов передаётся в основной цикл через calls.
+type botBackend struct {
+	ctx     context.Context
+	calls   chan func()
+	rt      *Runtime
+	started time.Time
+	reader  *LogReader // текущий reader основного цикла, меняется при смене log_file
+	reload  func() bool
+}
+
+var errStopping = errors.New("бот останавливается")
+
+// do выполняет fn в основном цикле и ждёт завершения
+func (b *botBackend) do(fn func()) error {
+	done := make(chan struct{})
+	select {
+	case b.calls <- func() { fn(); close(done) }:
+	case <-b.ctx.Done():
+		return errStopping
+	}
+	select {
+	case <-done:
+		return nil
+	case <-b.ctx.Done():
+		return errStopping
+	}
+}
+
+func (b *botBackend) Status() bot.Status {
+	var st bot.Status
+	b.do(func() {
+		rt := b.rt
+		st = bot.Status{
+			Started: b.started,
+			LogFile: rt.cfg.LogFile,
+			Offset:  (*b.reader).Offset(),
+		}
+		if last := rt.recent.last(1); len(last) == 1 {
+			st.LastAlert = &last[0].Entry
+		}
+		for _, name := range rt.cfg.DestinationNames() {
+			st.Destinations = append(st.Destinations, bot.DestinationStatus{
+				Name:   name,
+				Type:   rt.cfg.Destinations[name].Type,
+				Health: rt.outputs.health(name),
+			})
+		}
+		if _, ok := rt.outputs[subscriptionsOutput]; ok {
+			st.Destinations = append(st.Destinations, bot.DestinationStatus{
+				Name:   "подписчики",
+				Type:   "telegram",
+				Health: rt.outputs.health(subscriptionsOutput),
+			})
+		}
+	})
+	return st
+}
+
+func (b *botBackend) Recent(n int) []bot.Alert {
+	var alerts []bot.Alert
+	b.do(func() { alerts = b.rt.recent.last(n) })
+	return alerts
+}
+
+func (b *botBackend) Rules() bot.RulesInfo {
+	var info bot.RulesInfo
+	b.do(func() {
+		info = bot.RulesInfo{Levels: b.rt.cfg.Filters.Levels, Rules: b.rt.matcher.RuleNames()}
+	})
+	return info
+}
+
+func (b *botBackend) Reload() (bool, error) {
+	var applied bool
+	if err := b.do(func() { applied = b.reload() }); err != nil {
+		return false, err
+	}
+	return applied, nil
+}
+
+// Ack запоминает, кто принял алерт; после перезапуска бота отметка остаётся только в сообщении
+func (b *botBackend) Ack(id, by string) {
+	b.do(func() {
+		if a := b.rt.recent.find(id); a != nil {
+			a.AckedBy = by
+			a.AckedAt = time.Now()
+		}
+	})
+}
+
+// Mute создаёт silence на стабильный ключ алерта (правило и нормализованное сообщение или group_by)
+func (b *botBackend) Mute(fingerprint, by string, d time.Duration) (time.Time, error) {
+	var until time.Time
+	var err error
+	if stopErr := b.do(func() {
+		now := time.Now()
+		var sl silence.Silence
+		sl, err = b.rt.silences.Add(silence.Silence{
+			Fingerprint: fingerprint,
+			StartsAt:    now,
+			EndsAt:      now.Add(d),
+			CreatedBy:   "telegram " + by,
+			Comment:     "кнопка Mute под алертом",
+		})
+		until = sl.EndsAt
+	}); stopErr != nil {
+		return time.Time{}, stopErr
+	}
+	return until, err
+}
+
+func (b *botBackend) Raw(id string) (string, bool) {
+	var raw string
+	var ok bool
+	b.do(func() {
+		if a := b.rt.recent.find(id); a != nil {
+			raw, ok = a.Entry.Raw, true
+		}
+	})
+	return raw, ok
+}
+
+var errNoSubscriptions = errors.New("подписки недоступны: бот выключен в конфиге")
+
+func (b *botBackend) Subscribe(sub subscription.Subscription) (bool, error) {
+	var added bool
+	var err error
+	if stopErr := b.do(func() {
+		if b.rt.subscriptions == nil {
+			err = errNoSubscriptions
+			return
+		}
+		added, err = b.rt.subscriptions.Add(sub)
+	}); stopErr != nil {
+		return false, stopErr
+	}
+	if added {
+		log.Printf("Бот: %s подписался на %s %s", sub.User, sub.Kind, sub.Value)
+	}
+	return added, err
+}
+
+func (b *botBackend) Unsubscribe(userID int64, kind, value string) (int, error) {
+	var n int
+	var err error
+	if stopErr := b.do(func() {
+		if b.rt.subscriptions == nil {
+			err = errNoSubscriptions
+			return
+		}
+		n, err = b.rt.subscriptions.Remove(userID, kind, value)
+	}); stopErr != nil {
+		return 0, stopErr
+	}
+	return n, err
+}
+
+func (b *botBackend) Subscriptions(userID int64) []subscription.Subscription {
+	var subs []subscription.Subscription
+	b.do(func() {
+		if b.rt.subscriptions != nil {
+			subs = b.rt.subscriptions.List(userID)
+		}
+	})
+	return subs
+}
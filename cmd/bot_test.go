@@ -0,0 +1,48 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/sender"
+	"fmt"
+	"testing"
+)
+
+func TestRecentAlerts_Ring(t *testing.T) {
+	var r recentAlerts
+	if got := r.last(5); len(got) != 0 {
+		t.Fatalf("пустой буфер должен вернуть пусто, получено %d", len(got))
+	}
+
+	for i := 0; i < recentAlertsSize+3; i++ {
+		r.add(log_processing.LogEntry{Message: fmt.Sprint(i)})
+	}
+
+	got := r.last(3)
+	want := []string{fmt.Sprint(recentAlertsSize + 2), fmt.Sprint(recentAlertsSize + 1), fmt.Sprint(recentAlertsSize)}
+	for i := range want {
+		if got[i].Entry.Message != want[i] {
+			t.Fatalf("позиция %d: ожидается %s, получено %s", i, want[i], got[i].Entry.Message)
+		}
+	}
+	if n := len(r.last(1000)); n != recentAlertsSize {
+		t.Fatalf("ожидается не больше %d записей, получено %d", recentAlertsSize, n)
+	}
+}
+
+func TestRecentAlerts_Find(t *testing.T) {
+	var r recentAlerts
+	e := log_processing.LogEntry{Raw: "line", Fingerprint: "fp"}
+	r.add(e)
+
+	a := r.find(sender.AlertID(e))
+	if a == nil || a.Entry.Raw != "line" {
+		t.Fatalf("алерт должен находиться по AlertID, получено %+v", a)
+	}
+	a.AckedBy = "@dev"
+	if got := r.last(1)[0].AckedBy; got != "@dev" {
+		t.Fatalf("отметка Ack должна сохраниться в буфере, получено %q", got)
+	}
+	if r.find("unknown") != nil {
+		t.Fatal("неизвестный id не должен находиться")
+	}
+}
@@ -3,36 +3,78 @@ package main
 import (
 	"Bug_tracking_bot/internal/config"
 	"Bug_tracking_bot/internal/log_processing/filter_from_config"
+	"Bug_tracking_bot/internal/report"
+	"Bug_tracking_bot/internal/router"
+	"Bug_tracking_bot/internal/schedule"
 	"Bug_tracking_bot/internal/sender"
+	"Bug_tracking_bot/internal/silence"
+	"Bug_tracking_bot/internal/subscription"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 )
 
 type Runtime struct {
-	cfg      *config.Config
-	matcher  *filter_from_config.Matcher
-	sender   sender.Sender
-	cfgMTime time.Time
-	cfgPath  string
+	cfg       *config.Config
+	matcher   *filter_from_config.Matcher
+	senders   map[string]sender.Sender // по именам получателей из cfg.Destinations
+	router    *router.Router
+	outputs   outputs // очереди доставки, запускаются в main
+	silences  *silence.Store
+	scheduler *schedule.Scheduler
+	cfgMTime  time.Time
+	cfgPath   string
+	recent    recentAlerts   // последние отправленные алерты для команд бота
+	repeats   repeatTracker  // повторы для live_updates
+	digests   digestBatcher  // алерты, копящиеся для получателей с digest
+	resolves  resolveTracker // открытые инциденты для resolve_after_sec
+
+	deliveries chan deliveryResult // итоги доставки от worker'ов для архивов
+
+	subscriptions *subscription.Store // подписки /subscribe; nil, если бот выключен
+
+	report         *report.Collector // счётчики для отчёта; nil, если report выключен
+	reportSchedule report.Schedule
+	reportSaved    time.Time // когда счётчики последний раз сохранялись
 }
 
-// Загружаем конфиг, создаём matcher, создаём sender, запоминаем ModTime конфига, возвращаем объект структуры Runtime
+// Загружаем конфиг, создаём matcher, создаём sender для каждого получателя, запоминаем ModTime конфига, возвращаем объект структуры Runtime
 func buildRuntime(configPath string) (*Runtime, error) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка загрузки config.yaml: %w", err)
 	}
 
-	matcher, err := filter_from_config.NewMatcher(cfg.Filters.Levels, cfg.Filters.AlertRegex)
+	matcher, err := filter_from_config.New(cfg.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка компиляции правил в config.yaml: %w", err)
+	}
+
+	scheduler, err := schedule.New(cfg.Schedules)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора schedules в config.yaml: %w", err)
+	}
+
+	senders, err := buildSenders(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации sender: %w", err)
+	}
+
+	silences, err := silence.Open(cfg.Silences.File)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки silences (%s): %w", cfg.Silences.File, err)
+	}
+
+	subs, err := openSubscriptions(cfg, nil)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка компиляции regex в config.yaml: %w", err)
+		return nil, err
 	}
 
-	snd, err := sender.New(cfg)
+	stats, reportSchedule, err := openReport(cfg, nil, time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("ошибка инициализации sender (%s): %w", cfg.Sender.Type, err)
+		return nil, err
 	}
 
 	mt, err := configModTime(configPath)
@@ -41,22 +83,49 @@ func buildRuntime(configPath string) (*Runtime, error) {
 	}
 
 	log.Printf(
-		"Config загружен: файл с логами = %s отправитель = %s Время между чтением логов = %dms",
+		"Config загружен: файл с логами = %s получатели = %s Время между чтением логов = %dms",
 		cfg.LogFile,
-		cfg.Sender.Type,
+		strings.Join(cfg.DestinationNames(), ","),
 		cfg.PollIntervalMS,
 	)
 	log.Printf("Фильтр по уровням = %v (если пусто, все уровни)", cfg.Filters.Levels)
 
 	return &Runtime{
-		cfg:      cfg,
-		matcher:  matcher,
-		sender:   snd,
-		cfgMTime: mt,
-		cfgPath:  configPath,
+		cfg:       cfg,
+		matcher:   matcher,
+		senders:   senders,
+		router:    router.New(cfg),
+		outputs:   outputs{},
+		silences:  silences,
+		scheduler: scheduler,
+		cfgMTime:  mt,
+		cfgPath:   configPath,
+		repeats:   repeatTracker{},
+		digests:   digestBatcher{},
+		resolves:  resolveTracker{},
+
+		deliveries:     make(chan deliveryResult, deliveryQueue),
+		subscriptions:  subs,
+		report:         stats,
+		reportSchedule: reportSchedule,
 	}, nil
 }
 
+// openSubscriptions открывает файл подписок, если бот включён; текущее хранилище переиспользуется, если путь не менялся
+func openSubscriptions(cfg *config.Config, current *subscription.Store) (*subscription.Store, error) {
+	if !cfg.Bot.Enabled {
+		return nil, nil
+	}
+	if current != nil && current.Path() == cfg.Bot.SubscriptionsFile {
+		return current, nil
+	}
+	subs, err := subscription.Open(cfg.Bot.SubscriptionsFile)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки подписок (%s): %w", cfg.Bot.SubscriptionsFile, err)
+	}
+	return subs, nil
+}
+
 func configModTime(path string) (time.Time, error) {
 	info, err := os.Stat(path)
 	if err != nil {
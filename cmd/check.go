@@ -0,0 +1,158 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing/filter_from_config"
+	logproc "Bug_tracking_bot/internal/log_processing/formatter"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"Bug_tracking_bot/internal/router"
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Коды возврата check, как у grep: удобно использовать в CI
+const (
+	checkExitMatched   = 0 // хотя бы одна строка (или все строки при -all) совпала с правилами
+	checkExitNoMatch   = 1 // совпадений нет
+	checkExitErrorCode = 2 // ошибка конфига, чтения или (при -strict) парсинга
+)
+
+// runCheck прогоняет строки через parser, Matcher и маршруты из конфига и печатает результат.
+// Ничего не отправляет. Строки берутся из -line, -file или stdin.
+func runCheck(args []string, stdin io.Reader, out io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(out)
+	cfgPath := fs.String("config", configPath, "путь к config.yaml")
+	line := fs.String("line", "", "проверить одну строку лога")
+	file := fs.String("file", "", "проверить все строки файла ('-' = stdin)")
+	all := fs.Bool("all", false, "код 0 только если совпали все строки")
+	strict := fs.Bool("strict", false, "код 2, если хотя бы одна строка не распарсилась")
+	quiet := fs.Bool("q", false, "не печатать отформатированные сообщения")
+	if err := fs.Parse(args); err != nil {
+		return checkExitErrorCode
+	}
+
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		fmt.Fprintf(out, "ошибка загрузки %s: %v\n", *cfgPath, err)
+		return checkExitErrorCode
+	}
+
+	matcher, err := filter_from_config.New(cfg.Filters)
+	if err != nil {
+		fmt.Fprintf(out, "ошибка компиляции правил: %v\n", err)
+		return checkExitErrorCode
+	}
+
+	rt := router.New(cfg)
+
+	var lines []string
+	switch {
+	case *line != "":
+		lines = []string{*line}
+	case *file != "" && *file != "-":
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintf(out, "ошибка открытия файла: %v\n", err)
+			return checkExitErrorCode
+		}
+		defer f.Close()
+		lines, err = readCheckLines(f)
+		if err != nil {
+			fmt.Fprintf(out, "ошибка чтения файла: %v\n", err)
+			return checkExitErrorCode
+		}
+	default:
+		lines, err = readCheckLines(stdin)
+		if err != nil {
+			fmt.Fprintf(out, "ошибка чтения stdin: %v\n", err)
+			return checkExitErrorCode
+		}
+	}
+
+	var matched, parseFailed int
+	for i, raw := range lines {
+		fmt.Fprintf(out, "--- строка %d: %s\n", i+1, raw)
+
+		entry, err := parser.ParseLine(raw)
+		if err != nil {
+			parseFailed++
+			fmt.Fprintf(out, "распарсена: нет (%v)\n", err)
+			continue
+		}
+		fmt.Fprintf(out, "распарсена: да, уровень=%s сообщение=%q\n", entry.Level, entry.Message)
+
+		if rules := matcher.MatchingRules(entry); len(rules) > 0 {
+			fmt.Fprintf(out, "правила: %s\n", strings.Join(rules, ", "))
+		} else {
+			fmt.Fprintln(out, "правила: нет совпадений")
+		}
+		if !matcher.LevelAllowed(entry.Level) {
+			fmt.Fprintf(out, "уровень %s отфильтрован filters.levels\n", entry.Level)
+		}
+
+		entry, ok := matcher.Find(entry)
+		if !ok {
+			fmt.Fprintln(out, "результат: не будет отправлена")
+			continue
+		}
+		matched++
+
+		entry.Fingerprint = protect_from_duplicates.Key(entry, cfg.Dedup.GroupBy)
+		fmt.Fprintf(out, "результат: будет отправлена, ключ дедупликации=%s\n", entry.Fingerprint)
+		for _, name := range sortedKeys(entry.Fields) {
+			fmt.Fprintf(out, "поле %s=%q\n", name, entry.Fields[name])
+		}
+
+		dests := rt.Route(entry)
+		fmt.Fprintf(out, "получатели: %s\n", strings.Join(dests, ", "))
+
+		if !*quiet {
+			for _, name := range dests {
+				d := cfg.Destinations[name]
+				fmt.Fprintf(out, "%s (%s):\n%s", name, d.Type, logproc.Format(d.Type, entry, *d.Format))
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "итого: строк=%d совпало=%d не распарсено=%d\n", len(lines), matched, parseFailed)
+
+	switch {
+	case *strict && parseFailed > 0:
+		return checkExitErrorCode
+	case matched == 0:
+		return checkExitNoMatch
+	case *all && matched != len(lines):
+		return checkExitNoMatch
+	default:
+		return checkExitMatched
+	}
+}
+
+func readCheckLines(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, sc.Err()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCheckConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := `log_file: "logs.log"
+sender:
+  type: "stdout"
+filters:
+  levels: ["ERROR"]
+  alert_regex:
+    - "^Error processing request$"
+`
+	if err := os.WriteFile(path, []byte(cfg), 0o644); err != nil {
+		t.Fatalf("ошибка записи конфига: %v", err)
+	}
+	return path
+}
+
+func TestRunCheck_Matched(t *testing.T) {
+	var out bytes.Buffer
+	code := runCheck([]string{"-config", writeCheckConfig(t), "-line", "2026-02-25T17:24:25+03:00 [ERROR] Error processing request"}, nil, &out)
+
+	if code != checkExitMatched {
+		t.Fatalf("ожидается код %d, получено %d\n%s", checkExitMatched, code, out.String())
+	}
+	if !strings.Contains(out.String(), "^Error processing request$") {
+		t.Fatalf("ожидается имя совпавшего правила в выводе:\n%s", out.String())
+	}
+}
+
+func TestRunCheck_NoMatchAndAll(t *testing.T) {
+	input := "2026-02-25T17:24:25+03:00 [ERROR] Error processing request\n" +
+		"2026-02-25T17:24:26+03:00 [INFO] Error processing request\n"
+
+	var out bytes.Buffer
+	if code := runCheck([]string{"-config", writeCheckConfig(t), "-all"}, strings.NewReader(input), &out); code != checkExitNoMatch {
+		t.Fatalf("ожидается код %d при -all и отфильтрованной строке, получено %d", checkExitNoMatch, code)
+	}
+}
+
+func TestRunCheck_StrictParseError(t *testing.T) {
+	var out bytes.Buffer
+	code := runCheck([]string{"-config", writeCheckConfig(t), "-strict"}, strings.NewReader("невалидный лог\n"), &out)
+
+	if code != checkExitErrorCode {
+		t.Fatalf("ожидается код %d, получено %d", checkExitErrorCode, code)
+	}
+}
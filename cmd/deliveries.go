@@ -0,0 +1,59 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/sender"
+	"log"
+	"slices"
+)
+
+// Итоги доставки для архивов (file): worker каждого получателя сообщает, доставлен алерт или удалён без доставки,
+// а основной цикл дописывает итог в архивы, которым тот же алерт направлен маршрутами.
+
+// deliveryQueue сколько итогов ждут основного цикла; при переполнении итог только пишется в лог
+const deliveryQueue = 1000
+
+type deliveryResult struct {
+	dest string
+	msg  sender.Message
+	err  error // nil - доставлено
+}
+
+// deliveryReporter Options.Done для получателя dest; вызывается из goroutine worker'а и не блокирует её
+func deliveryReporter(dest string, results chan<- deliveryResult) func(sender.Message, error) {
+	return func(msg sender.Message, err error) {
+		// только алерты по маршрутам: повторы, сводки и личные сообщения подписчикам в архив не пишутся
+		if msg.Entry == nil || msg.Event != "" || !slices.Contains(msg.Destinations, dest) {
+			return
+		}
+		select {
+		case results <- deliveryResult{dest: dest, msg: msg, err: err}:
+		default:
+			log.Printf("Очередь итогов доставки переполнена, итог для %s не попадёт в архив", dest)
+		}
+	}
+}
+
+// handleDeliveries ставит накопленные итоги доставки в outbox архивов
+func handleDeliveries(rt *Runtime) {
+	for {
+		select {
+		case r := <-rt.deliveries:
+			recordDelivery(rt, r)
+		default:
+			return
+		}
+	}
+}
+
+func recordDelivery(rt *Runtime, r deliveryResult) {
+	d := &sender.Delivery{Destination: r.dest, Status: "sent"}
+	if r.err != nil {
+		d.Status, d.Error = "dropped", r.err.Error()
+	}
+	for _, name := range r.msg.Destinations {
+		if dc, ok := rt.cfg.Destinations[name]; !ok || dc.Type != "file" || name == r.dest {
+			continue
+		}
+		rt.outputs.enqueue(name, sender.Message{Entry: r.msg.Entry, Event: sender.EventDelivery, Delivery: d})
+	}
+}
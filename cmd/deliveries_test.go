@@ -0,0 +1,44 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/sender"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeliveries_RecordedInArchive(t *testing.T) {
+	ob, err := outbox.Open(filepath.Join(t.TempDir(), "outbox.archive.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer ob.Close()
+
+	rt := &Runtime{
+		cfg: &config.Config{Destinations: map[string]config.DestinationConfig{
+			"oncall":  {Type: "telegram"},
+			"archive": {Type: "file"},
+		}},
+		outputs:    outputs{"archive": {outbox: ob, worker: outbox.NewWorker(ob, &sender.StdoutSender{}, outbox.Options{})}},
+		deliveries: make(chan deliveryResult, deliveryQueue),
+	}
+
+	entry := &log_processing.LogEntry{Level: "ERROR", Message: "db timeout"}
+	routed := []string{"oncall", "archive"}
+	deliveryReporter("oncall", rt.deliveries)(sender.Message{Entry: entry, Destinations: routed}, errors.New("chat not found"))
+	deliveryReporter("archive", rt.deliveries)(sender.Message{Entry: entry, Destinations: routed}, nil)
+	deliveryReporter("oncall", rt.deliveries)(sender.Message{Entry: entry, Event: sender.EventRepeat, Destinations: routed}, nil)
+	handleDeliveries(rt)
+
+	if ob.Len() != 1 {
+		t.Fatalf("ожидается один итог: oncall; архив о себе и повторы не пишутся, в outbox %d", ob.Len())
+	}
+	rec, _ := ob.Peek()
+	d := rec.Message.Delivery
+	if rec.Message.Event != sender.EventDelivery || d == nil || d.Destination != "oncall" || d.Status != "dropped" || d.Error != "chat not found" {
+		t.Fatalf("неверный итог доставки: %+v %+v", rec.Message, d)
+	}
+}
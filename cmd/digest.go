@@ -0,0 +1,75 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	logproc "Bug_tracking_bot/internal/log_processing/formatter"
+	"Bug_tracking_bot/internal/sender"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// digestBatcher алерты, накопленные для получателей с digest: получатель -> пачка
+type digestBatcher map[string]*digestBatch
+
+type digestBatch struct {
+	entries []log_processing.LogEntry
+	started time.Time // когда в пачку попал первый алерт
+}
+
+// digestAccepts копится ли запись в дайджест или уходит сразу
+func digestAccepts(g *config.DigestConfig, entry log_processing.LogEntry) bool {
+	if g == nil {
+		return false
+	}
+	if len(g.Levels) > 0 && !slices.Contains(g.Levels, strings.ToUpper(entry.Level)) {
+		return false
+	}
+	if len(g.Rules) > 0 && !slices.Contains(g.Rules, entry.Rule) {
+		return false
+	}
+	return true
+}
+
+// add добавляет алерт в пачку получателя и возвращает её размер
+func (b digestBatcher) add(dest string, entry log_processing.LogEntry, now time.Time) int {
+	batch, ok := b[dest]
+	if !ok {
+		batch = &digestBatch{started: now}
+		b[dest] = batch
+	}
+	batch.entries = append(batch.entries, entry)
+	return len(batch.entries)
+}
+
+// handleDigests ставит в outbox сводки, у которых прошёл interval_sec; all - отправить все пачки сразу (при остановке)
+func handleDigests(rt *Runtime, now time.Time, all bool) {
+	for dest, batch := range rt.digests {
+		d, ok := rt.cfg.Destinations[dest]
+		if !ok {
+			log.Printf("Получатель %s удалён из конфига, дайджест не отправлен, алертов: %d", dest, len(batch.entries))
+			delete(rt.digests, dest)
+			continue
+		}
+		// после reload без digest накопленное отправляем сразу
+		if all || d.Digest == nil || now.Sub(batch.started) >= time.Duration(d.Digest.IntervalSec)*time.Second {
+			flushDigest(rt, dest, now)
+		}
+	}
+}
+
+// flushDigest отправляет пачку получателя одной сводкой
+func flushDigest(rt *Runtime, dest string, now time.Time) {
+	batch, ok := rt.digests[dest]
+	delete(rt.digests, dest)
+	if !ok || len(batch.entries) == 0 {
+		return
+	}
+
+	d := rt.cfg.Destinations[dest]
+	title := fmt.Sprintf("Дайджест %s–%s", batch.started.Format("15:04"), now.Format("15:04"))
+	rt.outputs.enqueue(dest, sender.Message{Text: logproc.FormatDigest(title, batch.entries, d.Type, *d.Format)})
+}
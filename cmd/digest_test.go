@@ -0,0 +1,169 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/router"
+	"Bug_tracking_bot/internal/schedule"
+	"Bug_tracking_bot/internal/sender"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDispatch_Digest(t *testing.T) {
+	ob, err := outbox.Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer ob.Close()
+	obTemplated, err := outbox.Open(filepath.Join(t.TempDir(), "outbox.templated.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer obTemplated.Close()
+
+	cfg := &config.Config{Destinations: map[string]config.DestinationConfig{"ops": {
+		Type:   "stdout",
+		Format: &config.FormatConfig{},
+		Digest: &config.DigestConfig{IntervalSec: 900, MaxBatch: 3, Levels: []string{"INFO"}},
+	}, "ops-templated": {
+		Type:   "stdout",
+		Format: &config.FormatConfig{MessageTemplate: "медленный запрос ({{.Rule}})", IncludeFingerprint: true},
+		Digest: &config.DigestConfig{IntervalSec: 900, MaxBatch: 100, Levels: []string{"INFO"}},
+	}}}
+	rt := &Runtime{
+		cfg:    cfg,
+		router: router.New(cfg),
+		outputs: outputs{
+			"ops":           {outbox: ob, worker: outbox.NewWorker(ob, &sender.StdoutSender{}, outbox.Options{})},
+			"ops-templated": {outbox: obTemplated, worker: outbox.NewWorker(obTemplated, &sender.StdoutSender{}, outbox.Options{})},
+		},
+		repeats: repeatTracker{},
+		digests: digestBatcher{},
+	}
+	pop := func() sender.Message {
+		t.Helper()
+		rec, ok := ob.Peek()
+		if !ok {
+			t.Fatal("ожидается сообщение в outbox")
+		}
+		if err := ob.Ack(rec.ID); err != nil {
+			t.Fatalf("ошибка ack: %v", err)
+		}
+		return rec.Message
+	}
+
+	// как в processBatch: разобранная строка с ключом дедупликации без group_by, уникальным для каждой строки
+	parse := func(line, rule string) log_processing.LogEntry {
+		t.Helper()
+		entry, err := parser.ParseLine(line)
+		if err != nil {
+			t.Fatalf("ошибка разбора строки: %v", err)
+		}
+		entry.Rule = rule
+		entry.Fingerprint = protect_from_duplicates.Key(entry, nil)
+		return entry
+	}
+	slow := parse("2026-03-01T12:00:00Z [INFO] slow query 120ms", "slow")
+	dispatch(rt, slow)
+	dispatch(rt, parse("2026-03-01T12:03:00Z [INFO] slow query 340ms", "slow"))
+	dispatch(rt, parse("2026-03-01T12:04:00Z [ERROR] boom", ""))
+
+	if ob.Len() != 1 || pop().Entry == nil {
+		t.Fatal("ERROR не подходит под digest.levels и должен уйти сразу")
+	}
+	if rec, ok := obTemplated.Peek(); !ok || rec.Message.Entry == nil {
+		t.Fatal("ERROR должен уйти сразу и второму получателю")
+	} else {
+		obTemplated.Ack(rec.ID)
+	}
+
+	handleDigests(rt, time.Now(), false)
+	if ob.Len() != 0 {
+		t.Fatal("до истечения interval_sec сводка отправляться не должна")
+	}
+
+	handleDigests(rt, time.Now().Add(16*time.Minute), false)
+	msg := pop()
+	if !strings.Contains(msg.Text, "Алертов: 2, групп: 1") || !strings.Contains(msg.Text, "INFO ×2 slow query <n>ms") {
+		t.Fatalf("ожидается одна группа из двух повторов с разным временем, получено:\n%s", msg.Text)
+	}
+	rec, ok := obTemplated.Peek()
+	if !ok {
+		t.Fatal("ожидается сводка второго получателя")
+	}
+	obTemplated.Ack(rec.ID)
+	if text := rec.Message.Text; !strings.Contains(text, "INFO ×2 медленный запрос (slow)") || !strings.Contains(text, "ключ: "+protect_from_duplicates.StableKey(slow)) {
+		t.Fatalf("сводка должна учитывать format получателя (шаблон и ключ), получено:\n%s", text)
+	}
+	if len(rt.digests) != 0 {
+		t.Fatal("после отправки пачка должна очищаться")
+	}
+
+	// max_batch отправляет сводку, не дожидаясь интервала
+	for range 3 {
+		dispatch(rt, slow)
+	}
+	if msg := pop(); !strings.Contains(msg.Text, "Алертов: 3") {
+		t.Fatalf("ожидается сводка по max_batch, получено:\n%s", msg.Text)
+	}
+
+	// при остановке накопленное отправляется сразу
+	dispatch(rt, slow)
+	handleDigests(rt, time.Now(), true)
+	if msg := pop(); !strings.Contains(msg.Text, "Алертов: 1") {
+		t.Fatalf("ожидается сводка при остановке, получено:\n%s", msg.Text)
+	}
+}
+
+func TestSendHeld_IncidentDestinationGetsEachEntry(t *testing.T) {
+	dir := t.TempDir()
+	open := func(name string) *outbox.Outbox {
+		ob, err := outbox.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ошибка открытия outbox: %v", err)
+		}
+		t.Cleanup(func() { ob.Close() })
+		return ob
+	}
+	obChat, obPager := open("outbox.chat.jsonl"), open("outbox.pager.jsonl")
+
+	cfg := &config.Config{Destinations: map[string]config.DestinationConfig{
+		"chat":  {Type: "stdout", Format: &config.FormatConfig{}},
+		"pager": {Type: "pagerduty", Format: &config.FormatConfig{}},
+	}}
+	rt := &Runtime{
+		cfg:    cfg,
+		router: router.New(cfg),
+		outputs: outputs{
+			"chat":  {outbox: obChat, worker: outbox.NewWorker(obChat, &sender.StdoutSender{}, outbox.Options{})},
+			"pager": {outbox: obPager, worker: outbox.NewWorker(obPager, &sender.StdoutSender{}, outbox.Options{})},
+		},
+		resolves: resolveTracker{},
+	}
+
+	ts := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	sendHeld(rt, schedule.Held{Window: "maintenance", Entries: []log_processing.LogEntry{
+		{Timestamp: ts, Level: "ERROR", Message: "db timeout"},
+		{Timestamp: ts, Level: "ERROR", Message: "disk full"},
+	}}, "Окно закончилось")
+
+	if obChat.Len() != 1 {
+		t.Fatalf("обычный получатель получает одну сводку, в outbox %d", obChat.Len())
+	}
+	if obPager.Len() != 2 {
+		t.Fatalf("pagerduty должен получить каждый придержанный алерт отдельно, в outbox %d", obPager.Len())
+	}
+	for range 2 {
+		rec, _ := obPager.Peek()
+		if rec.Message.Entry == nil {
+			t.Fatal("сообщение без Entry не откроет инцидент")
+		}
+		obPager.Ack(rec.ID)
+	}
+}
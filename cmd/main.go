@@ -1,37 +1,63 @@
 package main
 
 import (
+	"Bug_tracking_bot/internal/bot"
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
 	logproc "Bug_tracking_bot/internal/log_processing/formatter"
 	"Bug_tracking_bot/internal/log_processing/parser"
 	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
 	"Bug_tracking_bot/internal/reader"
+	"Bug_tracking_bot/internal/schedule"
+	"Bug_tracking_bot/internal/sender"
 	"context"
-	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
 
 type LogReader interface {
 	ReadNewLines() ([]string, error)
+	Offset() int64
 }
 
 const configPath = "config.yaml"
 
+// dedupWindow окно дедупликации; в нём же повторы дописываются в сообщение получателям с live_updates
+const dedupWindow = 5 * time.Minute
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check":
+			os.Exit(runCheck(os.Args[2:], os.Stdin, os.Stdout))
+		case "silence":
+			os.Exit(runSilence(os.Args[2:], os.Stdout))
+		}
+	}
+
 	rt, err := buildRuntime(configPath)
 	if err != nil {
 		log.Fatalf("Ошибка запуска: %v", err)
 	}
 
 	var fileReader LogReader = reader.NewFileReader(rt.cfg.LogFile)
-	deDupl := protect_from_duplicates.NewDeduplicator(5 * time.Minute)
+	deDupl := protect_from_duplicates.NewDeduplicator(dedupWindow)
+	held := schedule.NewHolder()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if err := rt.outputs.sync(ctx, rt.cfg, rt.senders, rt.deliveries); err != nil {
+		log.Fatalf("Ошибка запуска: %v", err)
+	}
+	defer rt.outputs.close()
+
 	go watchShutdown(cancel)
 
 	ticker := newPollTicker(rt.cfg.PollIntervalMS)
@@ -40,19 +66,67 @@ func main() {
 	reloadTicker := time.NewTicker(1 * time.Second)
 	defer reloadTicker.Stop()
 
+	var tgBot *bot.Bot
+	botCalls := make(chan func())
+	reload := func() bool {
+		var applied bool
+		ticker, fileReader, applied = handleReload(ctx, rt, ticker, fileReader)
+		if applied && tgBot != nil {
+			tgBot.Update(rt.cfg.Bot)
+		}
+		return applied
+	}
+
+	if rt.cfg.Bot.Enabled {
+		tgBot = bot.New(rt.cfg.Bot, &botBackend{
+			ctx:     ctx,
+			calls:   botCalls,
+			rt:      rt,
+			started: time.Now(),
+			reader:  &fileReader,
+			reload: func() bool {
+				// /reload применяет конфиг, даже если файл не менялся
+				mt := rt.cfgMTime
+				rt.cfgMTime = time.Time{}
+				if !reload() {
+					rt.cfgMTime = mt
+					return false
+				}
+				return true
+			},
+		})
+		go tgBot.Run(ctx)
+	}
+
 	log.Println("Старт работы Bug_tracking_bot")
 
 	for {
 		select {
 		case <-ctx.Done():
+			// придержанные алерты живут только в памяти: отправляем их сейчас, чтобы не потерять
+			flushHeldAlerts(rt, held)
+			// накопленные дайджесты сохраняются в outbox и будут доставлены, если не успеют сейчас
+			handleDigests(rt, time.Now(), true)
+			handleDeliveries(rt)
+			saveReport(rt)
 			log.Println("Завершение работы Bug_tracking_bot")
 			return
 
 		case <-reloadTicker.C:
-			ticker, fileReader = handleReload(rt, ticker, fileReader)
+			reload()
+			handleExpiredSilences(rt)
+			handleHeldAlerts(rt, held)
+			handleRepeats(rt, time.Now())
+			handleDigests(rt, time.Now(), false)
+			handleResolves(rt, time.Now())
+			handleDeliveries(rt)
+			handleReport(rt, time.Now())
 
 		case <-ticker.C:
-			processBatch(ctx, rt, fileReader, deDupl)
+			processBatch(rt, fileReader, deDupl, held)
+
+		case fn := <-botCalls:
+			fn()
 		}
 	}
 }
@@ -65,15 +139,20 @@ func watchShutdown(cancel context.CancelFunc) {
 	cancel()
 }
 
-func handleReload(rt *Runtime, ticker *time.Ticker, fileReader LogReader) (*time.Ticker, LogReader) {
+// handleReload применяет изменившийся конфиг; applied - конфиг был перезагружен
+func handleReload(ctx context.Context, rt *Runtime, ticker *time.Ticker, fileReader LogReader) (*time.Ticker, LogReader, bool) {
 	res, err := tryReloadRuntime(rt)
 	if err != nil {
 		log.Printf("Ошибка перезагрузки конфига: %v", err)
-		return ticker, fileReader
+		return ticker, fileReader, false
 	}
 
 	if !res.Applied {
-		return ticker, fileReader
+		return ticker, fileReader, false
+	}
+
+	if err := rt.outputs.sync(ctx, rt.cfg, rt.senders, rt.deliveries); err != nil {
+		log.Printf("Ошибка обновления получателей: %v", err)
 	}
 
 	if res.LogFileChanged {
@@ -86,16 +165,17 @@ func handleReload(rt *Runtime, ticker *time.Ticker, fileReader LogReader) (*time
 	}
 
 	log.Printf(
-		"config перезагружен: файл с логами = %s отправитель = %s Время между чтением логов = %dms",
+		"config перезагружен: файл с логами = %s получатели = %s Время между чтением логов = %dms",
 		rt.cfg.LogFile,
-		rt.cfg.Sender.Type,
+		strings.Join(rt.cfg.DestinationNames(), ","),
 		rt.cfg.PollIntervalMS,
 	)
 
-	return ticker, fileReader
+	return ticker, fileReader, true
 }
 
-func processBatch(ctx context.Context, rt *Runtime, fileReader LogReader, deDupl *protect_from_duplicates.Deduplicator,
+func processBatch(rt *Runtime, fileReader LogReader, deDupl *protect_from_duplicates.Deduplicator,
+	held *schedule.Holder,
 ) {
 	lines, err := fileReader.ReadNewLines()
 	if err != nil {
@@ -103,45 +183,172 @@ func processBatch(ctx context.Context, rt *Runtime, fileReader LogReader, deDupl
 		return
 	}
 
+	if err := rt.silences.Refresh(); err != nil {
+		log.Printf("Ошибка чтения silences, используем прежние: %v", err)
+	}
+	defer func() {
+		if err := rt.silences.Flush(); err != nil {
+			log.Printf("Ошибка сохранения статистики silences: %v", err)
+		}
+	}()
+
+	rt.report.Lines(rt.cfg.LogFile, len(lines))
 	for _, line := range lines {
 		entry, err := parser.ParseLine(line)
 		if err != nil {
+			if strings.TrimSpace(line) != "" {
+				rt.report.ParseFailure(rt.cfg.LogFile)
+			}
 			continue
 		}
+		entry.Source = rt.cfg.LogFile
+		rt.report.Parsed(entry)
 
-		if !rt.matcher.Match(entry) {
+		entry, ok := rt.matcher.Find(entry)
+		if !ok {
 			continue
 		}
 
-		if !deDupl.Allow(entry.Raw) {
+		entry.Fingerprint = protect_from_duplicates.Key(entry, rt.cfg.Dedup.GroupBy)
+		rt.report.Alert(entry, time.Now())
+		rt.resolves.seen(entry, time.Now())
+
+		// silence проверяем до дедупликации, чтобы после его окончания первый же повтор был отправлен
+		if _, silenced := rt.silences.Check(entry, time.Now()); silenced {
 			continue
 		}
 
-		var msg string
-		if rt.cfg.Sender.Type == "stdout" {
-			msg = logproc.FormatStdout(entry, rt.cfg.Format)
+		if !deDupl.AllowKey(entry.Fingerprint) {
+			rt.repeats.seen(entry, time.Now())
+			continue
 		}
-		if rt.cfg.Sender.Type == "telegram" {
-			msg = logproc.FormatTelegram(entry, rt.cfg.Format)
+
+		decision := rt.scheduler.Evaluate(entry, time.Now())
+		switch decision.Action {
+		case schedule.ActionDrop:
+			continue
+		case schedule.ActionDelay:
+			held.Add(decision, entry)
+			continue
+		case schedule.ActionDowngrade:
+			entry.Level = decision.Level
 		}
 
-		sendCtx, cancelSend := context.WithTimeout(ctx, 10*time.Second)
-		err = rt.sender.Send(sendCtx, msg)
-		cancelSend()
+		dispatch(rt, entry)
+	}
+}
 
-		if err == nil {
+// dispatch форматирует запись для каждого получателя по маршрутам и ставит в его outbox.
+// Получателям с digest подходящая запись не отправляется сразу, а копится для сводки.
+// Подписчикам бота (/subscribe) запись дополнительно уходит в личные сообщения.
+func dispatch(rt *Runtime, entry log_processing.LogEntry) {
+	names := rt.router.Route(entry)
+	if len(names) == 0 {
+		log.Printf("Алерт не подошёл ни под один маршрут и не отправлен (unrouted не задан): правило=%q уровень=%s %s",
+			entry.Rule, entry.Level, entry.Message)
+	}
+	rt.recent.add(entry)
+	for _, name := range names {
+		d := rt.cfg.Destinations[name]
+		if digestAccepts(d.Digest, entry) {
+			if rt.digests.add(name, entry, time.Now()) >= d.Digest.MaxBatch {
+				flushDigest(rt, name, time.Now())
+			}
 			continue
 		}
+		if liveUpdates(d) {
+			if rt.repeats.repeat(name, entry, time.Now()) {
+				continue
+			}
+			rt.repeats.sent(name, entry, time.Now())
+		}
+		if d.ResolveAfterSec > 0 {
+			rt.resolves.opened(name, entry, time.Now())
+		}
+		rt.outputs.enqueue(name, sender.Message{
+			Text:         logproc.Format(d.Type, entry, *d.Format),
+			Entry:        &entry,
+			Destinations: names,
+		})
+	}
 
-		if errors.Is(err, context.Canceled) {
-			continue
+	if rt.subscriptions == nil {
+		return
+	}
+	if users := rt.subscriptions.Match(entry, rt.cfg.Bot.AllowedUsers); len(users) > 0 {
+		recipients := make([]string, len(users))
+		for i, id := range users {
+			recipients[i] = strconv.FormatInt(id, 10)
 		}
+		rt.outputs.enqueue(subscriptionsOutput, sender.Message{
+			Text:         logproc.Format("telegram", entry, rt.cfg.Format),
+			Entry:        &entry,
+			Destinations: names,
+			Recipients:   recipients,
+		})
+	}
+}
 
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("Ошибка отправки, не успели отправить за отведенное время: %v", err)
-			continue
+// handleExpiredSilences отправляет сводку по каждому закончившемуся silence всем получателям и удаляет его из файла
+func handleExpiredSilences(rt *Runtime) {
+	expired, err := rt.silences.Expired(time.Now())
+	if err != nil {
+		log.Printf("Ошибка обработки закончившихся silences: %v", err)
+		return
+	}
+
+	for _, sl := range expired {
+		log.Printf("Silence %s закончился, подавлено алертов: %d", sl.ID, sl.Suppressed)
+		for _, name := range rt.router.All() {
+			d := rt.cfg.Destinations[name]
+			rt.outputs.enqueue(name, sender.Message{Text: logproc.FormatSilenceSummary(sl, d.Type)})
 		}
+	}
+}
 
-		log.Printf("Ошибка отправки: %v", err)
+// handleHeldAlerts отправляет одной сводкой алерты, придержанные окнами с action=delay, когда окно закончилось.
+// Каждый получатель получает сводку только по тем алертам, которые ему положены по маршрутам.
+func handleHeldAlerts(rt *Runtime, held *schedule.Holder) {
+	for _, hd := range held.Due(time.Now()) {
+		sendHeld(rt, hd, fmt.Sprintf("Окно %s закончилось, придержанные алерты", hd.Window))
+	}
+}
+
+// flushHeldAlerts при остановке отправляет придержанные алерты, не дожидаясь конца окон
+func flushHeldAlerts(rt *Runtime, held *schedule.Holder) {
+	for _, hd := range held.Drain() {
+		log.Printf("Окно %s ещё не закончилось, придержанные алерты отправляются при остановке: %d", hd.Window, len(hd.Entries))
+		sendHeld(rt, hd, fmt.Sprintf("Бот остановлен во время окна %s, придержанные алерты", hd.Window))
+	}
+}
+
+func sendHeld(rt *Runtime, hd schedule.Held, title string) {
+	byDest := make(map[string][]log_processing.LogEntry)
+	for _, e := range hd.Entries {
+		for _, name := range rt.router.Route(e) {
+			byDest[name] = append(byDest[name], e)
+		}
+	}
+
+	for name, entries := range byDest {
+		d, ok := rt.cfg.Destinations[name]
+		if !ok {
+			continue
+		}
+		if config.IncidentType(d.Type) {
+			// сводку такие получатели отбрасывают, и инцидент бы не открылся: каждая запись уходит отдельно, как из dispatch
+			for _, e := range entries {
+				if d.ResolveAfterSec > 0 {
+					rt.resolves.opened(name, e, time.Now())
+				}
+				rt.outputs.enqueue(name, sender.Message{
+					Text:         logproc.Format(d.Type, e, *d.Format),
+					Entry:        &e,
+					Destinations: rt.router.Route(e),
+				})
+			}
+			continue
+		}
+		rt.outputs.enqueue(name, sender.Message{Text: logproc.FormatDigest(title, entries, d.Type, *d.Format)})
 	}
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/sender"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// output получатель со своей очередью outbox и своим worker'ом:
+// сбой одного получателя не задерживает доставку остальным
+type output struct {
+	outbox *outbox.Outbox
+	worker *outbox.Worker
+	stop   context.CancelFunc
+}
+
+type outputs map[string]*output
+
+// subscriptionsOutput служебный получатель личных сообщений подписчикам бота
+const subscriptionsOutput = "_subscriptions"
+
+// buildSenders создаёт отправителей для всех получателей из конфига
+func buildSenders(cfg *config.Config) (map[string]sender.Sender, error) {
+	senders := make(map[string]sender.Sender, len(cfg.Destinations))
+	for _, name := range cfg.DestinationNames() {
+		d := cfg.Destinations[name]
+		snd, err := sender.New(d, cfg.Retry)
+		if err != nil {
+			return nil, fmt.Errorf("получатель %s (%s): %w", name, d.Type, err)
+		}
+		senders[name] = snd
+	}
+	if cfg.Bot.Enabled {
+		senders[subscriptionsOutput] = sender.WithRetry(sender.NewTelegramDirectSender(cfg.Bot.BotToken), cfg.Retry)
+	}
+	return senders, nil
+}
+
+// sync приводит набор получателей к конфигу: для новых открывает outbox и запускает worker,
+// у существующих подменяет отправителя, удалённые останавливает (их outbox остаётся на диске).
+// Итоги доставки worker'ы отправляют в results, см. deliveries.go.
+func (o outputs) sync(ctx context.Context, cfg *config.Config, senders map[string]sender.Sender, results chan<- deliveryResult) error {
+	for name, snd := range senders {
+		opts := outboxOptions(cfg)
+		opts.Done = deliveryReporter(name, results)
+
+		if out, ok := o[name]; ok {
+			out.worker.Update(snd, opts)
+			continue
+		}
+
+		ob, err := outbox.Open(cfg.OutboxFile(name))
+		if err != nil {
+			return fmt.Errorf("ошибка открытия outbox получателя %s: %w", name, err)
+		}
+		if n := ob.Len(); n > 0 {
+			log.Printf("В outbox получателя %s остались неотправленные сообщения: %d, отправляем", name, n)
+		}
+
+		wctx, stop := context.WithCancel(ctx)
+		out := &output{outbox: ob, worker: outbox.NewWorker(ob, snd, opts), stop: stop}
+		go out.worker.Run(wctx)
+		o[name] = out
+	}
+
+	for name, out := range o {
+		if _, ok := senders[name]; ok {
+			continue
+		}
+		log.Printf("Получатель %s удалён из конфига, в его outbox осталось сообщений: %d", name, out.outbox.Len())
+		out.stop()
+		out.outbox.Close()
+		delete(o, name)
+	}
+
+	return nil
+}
+
+// enqueue ставит сообщение в outbox получателя, доставкой занимается его worker
+func (o outputs) enqueue(name string, msg sender.Message) {
+	out, ok := o[name]
+	if !ok {
+		log.Printf("Неизвестный получатель %s, сообщение потеряно", name)
+		return
+	}
+	if _, err := out.outbox.Enqueue(msg); err != nil {
+		log.Printf("Ошибка записи в outbox получателя %s, сообщение потеряно: %v", name, err)
+		return
+	}
+	out.worker.Notify()
+}
+
+// health состояние доставки каждого получателя
+func (o outputs) health(name string) outbox.Health {
+	if out, ok := o[name]; ok {
+		return out.worker.Health()
+	}
+	return outbox.Health{}
+}
+
+func (o outputs) close() {
+	for _, out := range o {
+		out.stop()
+		out.outbox.Close()
+	}
+}
+
+func outboxOptions(cfg *config.Config) outbox.Options {
+	opts := outbox.Options{
+		RetryInterval: time.Duration(cfg.Outbox.RetryIntervalMS) * time.Millisecond,
+	}
+	if cfg.Retry.MaxElapsedMS > 0 {
+		// На все повторы отводится retry.max_elapsed_ms плюс запас на последнюю попытку
+		opts.SendTimeout = time.Duration(cfg.Retry.MaxElapsedMS)*time.Millisecond + 10*time.Second
+	}
+	if cfg.Outbox.MaxAgeSec > 0 {
+		opts.MaxAge = time.Duration(cfg.Outbox.MaxAgeSec) * time.Second
+	}
+	return opts
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"Bug_tracking_bot/internal/config"
 	"Bug_tracking_bot/internal/log_processing/filter_from_config"
-	"Bug_tracking_bot/internal/sender"
+	"Bug_tracking_bot/internal/router"
+	"Bug_tracking_bot/internal/schedule"
+	"Bug_tracking_bot/internal/silence"
 	"log"
 	"time"
 )
@@ -14,7 +16,7 @@ type ReloadResult struct {
 	PollIntervalChanged bool
 }
 
-// Смотрим ModTime конфига, если изменился, то загружаем новый, собираем новый matcher и sender, подменяем их в rt
+// Смотрим ModTime конфига, если изменился, то загружаем новый, собираем новый matcher и senders, подменяем их в rt
 // Если поменялся путь к логу, то пересоздаём fileReader, чтобы читать новый файл.
 func tryReloadRuntime(rt *Runtime) (ReloadResult, error) {
 	mt, err := configModTime(rt.cfgPath)
@@ -34,18 +36,49 @@ func tryReloadRuntime(rt *Runtime) (ReloadResult, error) {
 		return ReloadResult{}, nil
 	}
 
-	newMatcher, err := filter_from_config.NewMatcher(newCfg.Filters.Levels, newCfg.Filters.AlertRegex)
+	newMatcher, err := filter_from_config.New(newCfg.Filters)
 	if err != nil {
-		log.Printf("Ошибка компиляции regex, конфиг не применён: %v", err)
+		log.Printf("Ошибка компиляции правил, конфиг не применён: %v", err)
 		return ReloadResult{}, nil
 	}
 
-	newSender, err := sender.New(newCfg)
+	newScheduler, err := schedule.New(newCfg.Schedules)
+	if err != nil {
+		log.Printf("Ошибка разбора schedules, конфиг не применён: %v", err)
+		return ReloadResult{}, nil
+	}
+
+	newSenders, err := buildSenders(newCfg)
 	if err != nil {
 		log.Printf("Ошибка создания sender, конфиг не применён: %v", err)
 		return ReloadResult{}, nil
 	}
 
+	newSilences := rt.silences
+	if newCfg.Silences.File != rt.silences.Path() {
+		newSilences, err = silence.Open(newCfg.Silences.File)
+		if err != nil {
+			log.Printf("Ошибка загрузки silences, конфиг не применён: %v", err)
+			return ReloadResult{}, nil
+		}
+	}
+
+	newSubs, err := openSubscriptions(newCfg, rt.subscriptions)
+	if err != nil {
+		log.Printf("Ошибка загрузки подписок, конфиг не применён: %v", err)
+		return ReloadResult{}, nil
+	}
+
+	newReport, newReportSchedule, err := openReport(newCfg, rt.report, time.Now())
+	if err != nil {
+		log.Printf("%v, конфиг не применён", err)
+		return ReloadResult{}, nil
+	}
+	if rt.report != newReport {
+		// report выключен или сменился файл: сохраняем накопленное в прежний
+		saveReport(rt)
+	}
+
 	result := ReloadResult{
 		Applied:             true,
 		LogFileChanged:      rt.cfg.LogFile != newCfg.LogFile,
@@ -54,7 +87,13 @@ func tryReloadRuntime(rt *Runtime) (ReloadResult, error) {
 
 	rt.cfg = newCfg
 	rt.matcher = newMatcher
-	rt.sender = newSender
+	rt.senders = newSenders
+	rt.router = router.New(newCfg)
+	rt.silences = newSilences
+	rt.scheduler = newScheduler
+	rt.subscriptions = newSubs
+	rt.report = newReport
+	rt.reportSchedule = newReportSchedule
 	rt.cfgMTime = mt
 
 	log.Println("Новый конфиг успешно применён")
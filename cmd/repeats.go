@@ -0,0 +1,95 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"Bug_tracking_bot/internal/sender"
+	"time"
+)
+
+// repeatStateTTL сколько помнить алерт без новых повторов; дальше его повторы снова пройдут дедупликацию как новый алерт
+const repeatStateTTL = time.Hour
+
+// repeatTracker считает повторы, подавленные дедупликацией, для получателей telegram с live_updates.
+// Обновления отправляются не чаще live_update_interval_sec, последнее - даже если повторы прекратились.
+// Алерты сопоставляются по StableKey: без group_by ключ дедупликации у каждой строки свой.
+type repeatTracker map[string]map[string]*repeatState // получатель -> StableKey
+
+type repeatState struct {
+	entry     log_processing.LogEntry // первый алерт, его сообщение и обновляется
+	count     int                     // вместе с первым
+	sentAt    time.Time
+	lastSeen  time.Time
+	flushed   int // count на момент последнего обновления
+	lastFlush time.Time
+}
+
+// liveUpdates дописывает ли получатель повторы в первое сообщение
+func liveUpdates(d config.DestinationConfig) bool {
+	return d.Type == "telegram" && d.Telegram.LiveUpdates
+}
+
+// sent алерт отправлен получателю новым сообщением: счёт повторов начинается заново
+func (t repeatTracker) sent(dest string, entry log_processing.LogEntry, now time.Time) {
+	if t[dest] == nil {
+		t[dest] = make(map[string]*repeatState)
+	}
+	t[dest][protect_from_duplicates.StableKey(entry)] = &repeatState{entry: entry, count: 1, sentAt: now, lastSeen: now, flushed: 1, lastFlush: now}
+}
+
+// repeat алерт прошёл дедупликацию (например, та же ошибка с другим временем), но его первое сообщение
+// получателю ушло не раньше окна дедупликации: вместо нового сообщения учитывается повтор
+func (t repeatTracker) repeat(dest string, entry log_processing.LogEntry, now time.Time) bool {
+	st, ok := t[dest][protect_from_duplicates.StableKey(entry)]
+	if !ok || now.Sub(st.sentAt) > dedupWindow {
+		return false
+	}
+	st.count++
+	st.lastSeen = now
+	return true
+}
+
+// seen повтор подавлен дедупликацией; учитывается у тех получателей, которым ушёл первый алерт
+func (t repeatTracker) seen(entry log_processing.LogEntry, now time.Time) {
+	key := protect_from_duplicates.StableKey(entry)
+	for _, states := range t {
+		if st, ok := states[key]; ok {
+			st.count++
+			st.lastSeen = now
+		}
+	}
+}
+
+// handleRepeats ставит в outbox обновления счётчиков повторов, для которых прошёл интервал
+func handleRepeats(rt *Runtime, now time.Time) {
+	for dest, states := range rt.repeats {
+		d, ok := rt.cfg.Destinations[dest]
+		if !ok || !liveUpdates(d) {
+			delete(rt.repeats, dest)
+			continue
+		}
+		interval := time.Duration(d.Telegram.LiveUpdateIntervalSec) * time.Second
+
+		for key, st := range states {
+			if st.count == st.flushed {
+				if now.Sub(st.lastSeen) > repeatStateTTL {
+					delete(states, key)
+				}
+				continue
+			}
+			if now.Sub(st.lastFlush) < interval {
+				continue
+			}
+
+			entry := st.entry
+			rt.outputs.enqueue(dest, sender.Message{
+				Entry:  &entry,
+				Event:  sender.EventRepeat,
+				Repeat: &sender.Repeat{Count: st.count, LastSeen: st.lastSeen},
+			})
+			st.flushed = st.count
+			st.lastFlush = now
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/router"
+	"Bug_tracking_bot/internal/sender"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleRepeats_Throttled(t *testing.T) {
+	ob, err := outbox.Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer ob.Close()
+
+	live := config.DestinationConfig{Type: "telegram", Telegram: config.TelegramConfig{LiveUpdates: true, LiveUpdateIntervalSec: 30}}
+	rt := &Runtime{
+		cfg:     &config.Config{Destinations: map[string]config.DestinationConfig{"tg": live}},
+		outputs: outputs{"tg": {outbox: ob, worker: outbox.NewWorker(ob, &sender.StdoutSender{}, outbox.Options{})}},
+		repeats: repeatTracker{},
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := log_processing.LogEntry{Message: "boom", Fingerprint: "fp"}
+	rt.repeats.sent("tg", entry, start)
+	rt.repeats.seen(entry, start.Add(5*time.Second))
+	rt.repeats.seen(entry, start.Add(10*time.Second))
+	rt.repeats.seen(log_processing.LogEntry{Fingerprint: "other"}, start)
+
+	handleRepeats(rt, start.Add(20*time.Second))
+	if ob.Len() != 0 {
+		t.Fatal("до истечения интервала обновление отправляться не должно")
+	}
+
+	handleRepeats(rt, start.Add(31*time.Second))
+	rec, ok := ob.Peek()
+	if !ok || rec.Message.Event != sender.EventRepeat || rec.Message.Repeat.Count != 3 {
+		t.Fatalf("ожидается обновление с 3 повторами, получено %+v", rec.Message)
+	}
+	if !rec.Message.Repeat.LastSeen.Equal(start.Add(10 * time.Second)) {
+		t.Fatalf("неожиданное время последнего повтора: %v", rec.Message.Repeat.LastSeen)
+	}
+
+	// без новых повторов обновлений нет
+	handleRepeats(rt, start.Add(2*time.Minute))
+	if ob.Len() != 1 {
+		t.Fatalf("без новых повторов обновление не нужно, в outbox %d", ob.Len())
+	}
+
+	// получатель без live_updates после reload забывается
+	rt.cfg.Destinations["tg"] = config.DestinationConfig{Type: "telegram"}
+	handleRepeats(rt, start.Add(3*time.Minute))
+	if len(rt.repeats) != 0 {
+		t.Fatal("состояние получателя без live_updates должно удаляться")
+	}
+}
+
+func TestDispatch_LiveUpdatesRepeatWithNewTimestamp(t *testing.T) {
+	ob, err := outbox.Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer ob.Close()
+
+	cfg := &config.Config{Destinations: map[string]config.DestinationConfig{"tg": {
+		Type:     "telegram",
+		Format:   &config.FormatConfig{},
+		Telegram: config.TelegramConfig{LiveUpdates: true, LiveUpdateIntervalSec: 30},
+	}}}
+	rt := &Runtime{
+		cfg:     cfg,
+		router:  router.New(cfg),
+		outputs: outputs{"tg": {outbox: ob, worker: outbox.NewWorker(ob, &sender.StdoutSender{}, outbox.Options{})}},
+		repeats: repeatTracker{},
+	}
+
+	// как в processBatch без group_by: у каждой строки свой ключ дедупликации, и дедупликация их не подавляет
+	for _, line := range []string{
+		"2026-03-01T12:00:00Z [ERROR] db timeout",
+		"2026-03-01T12:00:05Z [ERROR] db timeout",
+		"2026-03-01T12:00:09Z [ERROR] db timeout",
+	} {
+		entry, err := parser.ParseLine(line)
+		if err != nil {
+			t.Fatalf("ошибка разбора строки: %v", err)
+		}
+		entry.Fingerprint = protect_from_duplicates.Key(entry, nil)
+		dispatch(rt, entry)
+	}
+
+	rec, ok := ob.Peek()
+	if ob.Len() != 1 || !ok || rec.Message.Event != "" {
+		t.Fatalf("повторы должны учитываться в первом сообщении, а не уходить новыми, в outbox %d", ob.Len())
+	}
+	if err := ob.Ack(rec.ID); err != nil {
+		t.Fatalf("ошибка ack: %v", err)
+	}
+
+	handleRepeats(rt, time.Now().Add(time.Minute))
+	rec, ok = ob.Peek()
+	if ob.Len() != 1 || !ok || rec.Message.Event != sender.EventRepeat || rec.Message.Repeat.Count != 3 {
+		t.Fatalf("ожидается одно обновление первого сообщения с 3 повторами, в outbox %d: %+v", ob.Len(), rec.Message)
+	}
+	if !strings.Contains(rec.Message.Entry.Raw, "12:00:00") {
+		t.Fatalf("обновляться должно первое сообщение, получено %q", rec.Message.Entry.Raw)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	logproc "Bug_tracking_bot/internal/log_processing/formatter"
+	"Bug_tracking_bot/internal/report"
+	"Bug_tracking_bot/internal/sender"
+	"fmt"
+	"log"
+	"time"
+)
+
+// reportSaveInterval как часто счётчики отчёта сбрасываются на диск; при остановке - сразу
+const reportSaveInterval = time.Minute
+
+// openReport открывает счётчики отчёта, если он включён; текущие переиспользуются, если файл не менялся
+func openReport(cfg *config.Config, current *report.Collector, now time.Time) (*report.Collector, report.Schedule, error) {
+	if !cfg.Report.Enabled {
+		return nil, report.Schedule{}, nil
+	}
+	sch, err := report.NewSchedule(cfg.Report.Every, cfg.Report.At, cfg.Report.Weekday, cfg.Schedules.Timezone)
+	if err != nil {
+		return nil, report.Schedule{}, fmt.Errorf("ошибка разбора report: %w", err)
+	}
+	if current != nil && current.Path() == cfg.Report.StatsFile {
+		return current, sch, nil
+	}
+	c, err := report.Open(cfg.Report.StatsFile, now)
+	if err != nil {
+		return nil, report.Schedule{}, fmt.Errorf("ошибка загрузки статистики отчёта (%s): %w", cfg.Report.StatsFile, err)
+	}
+	return c, sch, nil
+}
+
+// handleReport учитывает ошибки доставки, периодически сохраняет счётчики
+// и, когда подошло время, ставит отчёт в outbox получателям и начинает новый период
+func handleReport(rt *Runtime, now time.Time) {
+	if rt.report == nil {
+		return
+	}
+	for name := range rt.outputs {
+		h := rt.outputs.health(name)
+		rt.report.Delivery(name, h.Failed, h.Dropped)
+	}
+
+	if !now.Before(rt.reportSchedule.Next(rt.report.Since())) {
+		r := rt.report.Build(now)
+		names := rt.cfg.Report.Destinations
+		if len(names) == 0 {
+			// по умолчанию - всем, кроме инцидентных получателей: сообщение без алерта они не принимают
+			for _, name := range rt.router.All() {
+				if !config.IncidentType(rt.cfg.Destinations[name].Type) {
+					names = append(names, name)
+				}
+			}
+		}
+		for _, name := range names {
+			d, ok := rt.cfg.Destinations[name]
+			if !ok {
+				continue
+			}
+			rt.outputs.enqueue(name, sender.Message{Text: logproc.FormatReport(r, d.Type)})
+		}
+		log.Printf("Отчёт за %s - %s поставлен в очередь: %d получателей",
+			r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04"), len(names))
+		rt.report.Reset(now)
+		rt.reportSaved = time.Time{}
+	}
+
+	if now.Sub(rt.reportSaved) >= reportSaveInterval {
+		saveReport(rt)
+		rt.reportSaved = now
+	}
+}
+
+func saveReport(rt *Runtime) {
+	if err := rt.report.Save(); err != nil {
+		log.Printf("Ошибка сохранения статистики отчёта: %v", err)
+	}
+}
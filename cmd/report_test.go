@@ -0,0 +1,87 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/report"
+	"Bug_tracking_bot/internal/router"
+	"Bug_tracking_bot/internal/sender"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleReport_SendsWhenDue(t *testing.T) {
+	dir := t.TempDir()
+	ob, err := outbox.Open(filepath.Join(dir, "outbox.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer ob.Close()
+	obPD, err := outbox.Open(filepath.Join(dir, "outbox.pd.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer obPD.Close()
+
+	// report.destinations не задан: отчёт уходит всем, кроме инцидентных получателей
+	cfg := &config.Config{
+		Destinations: map[string]config.DestinationConfig{"ops": {Type: "stdout"}, "pd": {Type: "pagerduty"}},
+		Report:       config.ReportConfig{Enabled: true, Every: "daily", At: "09:00", StatsFile: filepath.Join(dir, "stats.json")},
+		Schedules:    config.SchedulesConfig{Timezone: "UTC"},
+	}
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	stats, sch, err := openReport(cfg, nil, start)
+	if err != nil {
+		t.Fatalf("ошибка открытия статистики: %v", err)
+	}
+	rt := &Runtime{
+		cfg:    cfg,
+		router: router.New(cfg),
+		outputs: outputs{
+			"ops": {outbox: ob, worker: outbox.NewWorker(ob, &sender.StdoutSender{}, outbox.Options{})},
+			"pd":  {outbox: obPD, worker: outbox.NewWorker(obPD, &sender.StdoutSender{}, outbox.Options{})},
+		},
+		report:         stats,
+		reportSchedule: sch,
+	}
+
+	rt.report.Lines("app.log", 10)
+	rt.report.Parsed(log_processing.LogEntry{Level: "ERROR", Message: "db timeout after 30s"})
+
+	handleReport(rt, start.Add(30*time.Minute))
+	if ob.Len() != 0 {
+		t.Fatal("до 09:00 отчёт отправляться не должен")
+	}
+
+	due := time.Date(2026, 3, 1, 9, 0, 5, 0, time.UTC)
+	handleReport(rt, due)
+	rec, ok := ob.Peek()
+	if !ok {
+		t.Fatal("в 09:00 ожидается отчёт в outbox")
+	}
+	for _, want := range []string{"app.log: 10", "×1 db timeout after <n>s", "Новые алерты: 0"} {
+		if !strings.Contains(rec.Message.Text, want) {
+			t.Fatalf("в отчёте нет %q:\n%s", want, rec.Message.Text)
+		}
+	}
+	if obPD.Len() != 0 {
+		t.Fatal("pagerduty отчёты не принимает, ставить его в очередь нельзя")
+	}
+	if !rt.report.Since().Equal(due) {
+		t.Fatalf("после отчёта начинается новый период, начало %v", rt.report.Since())
+	}
+
+	// новый период сохранён на диск и переживает перезапуск
+	reopened, err := report.Open(cfg.Report.StatsFile, due.Add(time.Hour))
+	if err != nil || !reopened.Since().Equal(due) {
+		t.Fatalf("ожидается сохранённый период с %v, получено %v (%v)", due, reopened.Since(), err)
+	}
+
+	handleReport(rt, due.Add(time.Hour))
+	if ob.Len() != 1 {
+		t.Fatalf("следующий отчёт только завтра, в outbox %d", ob.Len())
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"Bug_tracking_bot/internal/sender"
+	"time"
+)
+
+// resolveTracker инциденты, открытые у получателей с resolve_after_sec, по StableKey ошибки.
+// Ошибка, которая не повторялась resolve_after_sec, считается восстановленной: получатель получает событие resolve.
+type resolveTracker map[string]*resolveState
+
+type resolveState struct {
+	entry    log_processing.LogEntry
+	dests    map[string]bool // кому ушёл алерт
+	lastSeen time.Time
+}
+
+// opened алерт отправлен получателю с resolve_after_sec
+func (t resolveTracker) opened(dest string, entry log_processing.LogEntry, now time.Time) {
+	key := protect_from_duplicates.StableKey(entry)
+	st, ok := t[key]
+	if !ok {
+		st = &resolveState{entry: entry, dests: make(map[string]bool)}
+		t[key] = st
+	}
+	st.dests[dest] = true
+	st.lastSeen = now
+}
+
+// seen ошибка снова встретилась, даже если дедупликация или silence её подавили: инцидент ещё не восстановлен
+func (t resolveTracker) seen(entry log_processing.LogEntry, now time.Time) {
+	if len(t) == 0 {
+		return
+	}
+	if st, ok := t[protect_from_duplicates.StableKey(entry)]; ok {
+		st.lastSeen = now
+	}
+}
+
+// handleResolves ставит в outbox события resolve для ошибок, которые не повторялись resolve_after_sec
+func handleResolves(rt *Runtime, now time.Time) {
+	for key, st := range rt.resolves {
+		for dest := range st.dests {
+			d, ok := rt.cfg.Destinations[dest]
+			if !ok || d.ResolveAfterSec == 0 {
+				delete(st.dests, dest)
+				continue
+			}
+			if now.Sub(st.lastSeen) < time.Duration(d.ResolveAfterSec)*time.Second {
+				continue
+			}
+			entry := st.entry
+			rt.outputs.enqueue(dest, sender.Message{Entry: &entry, Event: sender.EventResolve})
+			delete(st.dests, dest)
+		}
+		if len(st.dests) == 0 {
+			delete(rt.resolves, key)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/sender"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHandleResolves_AfterQuietPeriod(t *testing.T) {
+	ob, err := outbox.Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatalf("ошибка открытия outbox: %v", err)
+	}
+	defer ob.Close()
+
+	rt := &Runtime{
+		cfg: &config.Config{Destinations: map[string]config.DestinationConfig{
+			"pager": {Type: "pagerduty", ResolveAfterSec: 600},
+		}},
+		outputs:  outputs{"pager": {outbox: ob, worker: outbox.NewWorker(ob, &sender.StdoutSender{}, outbox.Options{})}},
+		resolves: resolveTracker{},
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first := log_processing.LogEntry{Rule: "db", Message: "db timeout after 30s", Raw: "2026-03-01T12:00:00Z [ERROR] db timeout after 30s"}
+	rt.resolves.opened("pager", first, start)
+
+	// повтор с другим временем и числом в строке - та же ошибка, восстановление откладывается
+	rt.resolves.seen(log_processing.LogEntry{Rule: "db", Message: "db timeout after 45s", Raw: "2026-03-01T12:08:00Z [ERROR] db timeout after 45s"}, start.Add(8*time.Minute))
+
+	handleResolves(rt, start.Add(12*time.Minute))
+	if ob.Len() != 0 {
+		t.Fatal("ошибка повторялась меньше resolve_after_sec назад, resolve рано")
+	}
+
+	handleResolves(rt, start.Add(18*time.Minute))
+	rec, ok := ob.Peek()
+	if !ok || rec.Message.Event != sender.EventResolve || rec.Message.Entry.Message != first.Message {
+		t.Fatalf("ожидается resolve по первому алерту, получено %+v", rec.Message)
+	}
+	if len(rt.resolves) != 0 {
+		t.Fatal("после resolve инцидент должен забываться")
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/silence"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// fieldFlags повторяемый флаг -field key=value
+type fieldFlags map[string]string
+
+func (f fieldFlags) String() string {
+	parts := make([]string, 0, len(f))
+	for k, v := range f {
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f fieldFlags) Set(v string) error {
+	k, val, ok := strings.Cut(v, "=")
+	if !ok || strings.TrimSpace(k) == "" {
+		return fmt.Errorf("ожидается key=value, получено %q", v)
+	}
+	f[strings.TrimSpace(k)] = val
+	return nil
+}
+
+// runSilence управление silences: add, list, expire.
+// Бот перечитывает файл silences сам, перезапуск не нужен.
+func runSilence(args []string, out io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(out, "использование: silence add|list|expire [флаги]")
+		return 2
+	}
+
+	sub, args := args[0], args[1:]
+	fs := flag.NewFlagSet("silence "+sub, flag.ContinueOnError)
+	fs.SetOutput(out)
+	cfgPath := fs.String("config", configPath, "путь к config.yaml")
+
+	fields := fieldFlags{}
+	rule := fs.String("rule", "", "имя правила (name из filters.rules или regex из filters.alert_regex)")
+	level := fs.String("level", "", "уровень лога")
+	fp := fs.String("fingerprint", "", "ключ дедупликации")
+	fs.Var(fields, "field", "поле из именованной группы, key=value (можно несколько раз)")
+	start := fs.String("start", "", "начало в формате RFC3339 (по умолчанию сейчас)")
+	end := fs.String("end", "", "окончание в формате RFC3339")
+	duration := fs.Duration("duration", time.Hour, "длительность, если -end не задан")
+	author := fs.String("author", os.Getenv("USER"), "автор")
+	comment := fs.String("comment", "", "комментарий")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(*cfgPath)
+	if err != nil {
+		fmt.Fprintf(out, "ошибка загрузки %s: %v\n", *cfgPath, err)
+		return 2
+	}
+
+	store, err := silence.Open(cfg.Silences.File)
+	if err != nil {
+		fmt.Fprintf(out, "ошибка открытия silences: %v\n", err)
+		return 2
+	}
+
+	now := time.Now()
+
+	switch sub {
+	case "add":
+		sl := silence.Silence{
+			Rule:        *rule,
+			Level:       strings.ToUpper(strings.TrimSpace(*level)),
+			Fingerprint: *fp,
+			Fields:      fields,
+			StartsAt:    now,
+			CreatedBy:   *author,
+			Comment:     *comment,
+		}
+		if len(fields) == 0 {
+			sl.Fields = nil
+		}
+		if *start != "" {
+			if sl.StartsAt, err = time.Parse(time.RFC3339, *start); err != nil {
+				fmt.Fprintf(out, "неверный -start: %v\n", err)
+				return 2
+			}
+		}
+		sl.EndsAt = sl.StartsAt.Add(*duration)
+		if *end != "" {
+			if sl.EndsAt, err = time.Parse(time.RFC3339, *end); err != nil {
+				fmt.Fprintf(out, "неверный -end: %v\n", err)
+				return 2
+			}
+		}
+
+		sl, err = store.Add(sl)
+		if err != nil {
+			fmt.Fprintf(out, "ошибка добавления silence: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(out, "добавлен silence %s до %s\n", sl.ID, sl.EndsAt.Format(time.RFC3339))
+
+	case "list":
+		for _, sl := range store.List() {
+			state := "ожидает"
+			if sl.Active(now) {
+				state = "активен"
+			} else if !now.Before(sl.EndsAt) {
+				state = "закончился"
+			}
+			fmt.Fprintf(out, "%s [%s] %s - %s автор=%s подавлено=%d rule=%q level=%q fingerprint=%q fields=%v %s\n",
+				sl.ID, state, sl.StartsAt.Format(time.RFC3339), sl.EndsAt.Format(time.RFC3339),
+				sl.CreatedBy, sl.Suppressed, sl.Rule, sl.Level, sl.Fingerprint, sl.Fields, sl.Comment)
+		}
+
+	case "expire":
+		if fs.NArg() != 1 {
+			fmt.Fprintln(out, "использование: silence expire <id>")
+			return 2
+		}
+		if err := store.Expire(fs.Arg(0), now); err != nil {
+			fmt.Fprintf(out, "ошибка: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(out, "silence %s завершён\n", fs.Arg(0))
+
+	default:
+		fmt.Fprintf(out, "неизвестная команда silence %s\n", sub)
+		return 2
+	}
+
+	return 0
+}
@@ -2,4 +2,4 @@ module Bug_tracking_bot
 
 go 1.24
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/sender"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Интерактивный бот: получает команды через getUpdates (long polling) и отвечает в тот же чат.
+// Команды принимаются только от пользователей из allowed_users.
+
+const (
+	replyTimeout = 15 * time.Second
+	pollPause    = 5 * time.Second // пауза после ошибки getUpdates
+)
+
+// Bot обработчик команд
+type Bot struct {
+	client      *sender.TelegramClient
+	backend     Backend
+	pollTimeout int // секунды
+	offset      int64
+
+	mu       sync.Mutex
+	allowed  map[int64]bool
+	maxLines int // ограничение вывода /tail и /grep
+
+	searches  chan struct{}  // занятые слоты /tail и /grep, не больше maxSearches одновременно
+	searching sync.WaitGroup // идущие поиски, для тестов
+}
+
+// update из ответа getUpdates
+type update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *message       `json:"message"`
+	CallbackQuery *callbackQuery `json:"callback_query"`
+}
+
+type message struct {
+	MessageID int   `json:"message_id"`
+	From      *user `json:"from"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text        string                 `json:"text"`
+	Entities    json.RawMessage        `json:"entities"` // разметка текста, нужна, чтобы отредактировать сообщение без потери форматирования
+	ReplyMarkup *sender.InlineKeyboard `json:"reply_markup"`
+}
+
+type user struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+}
+
+// String подпись пользователя в сообщениях и логах
+func (u *user) String() string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	if u.FirstName != "" {
+		return u.FirstName
+	}
+	return "id " + itoa(u.ID)
+}
+
+func New(cfg config.BotConfig, backend Backend) *Bot {
+	b := &Bot{
+		// запрос getUpdates длится до poll_timeout секунд, даём запас на сеть
+		client:      sender.NewTelegramClient(cfg.BotToken, time.Duration(cfg.PollTimeoutSec)*time.Second+10*time.Second),
+		backend:     backend,
+		pollTimeout: cfg.PollTimeoutSec,
+		searches:    make(chan struct{}, maxSearches),
+	}
+	b.Update(cfg)
+	return b
+}
+
+// SetBaseURL адрес Bot API, для тестов с локальной заглушкой
+func (b *Bot) SetBaseURL(u string) {
+	b.client.SetBaseURL(u)
+}
+
+// Update применяет allowed_users и max_output_lines после перезагрузки конфига; токен и таймаут меняются только перезапуском
+func (b *Bot) Update(cfg config.BotConfig) {
+	allowed := make(map[int64]bool, len(cfg.AllowedUsers))
+	for _, id := range cfg.AllowedUsers {
+		allowed[id] = true
+	}
+	b.mu.Lock()
+	b.allowed = allowed
+	b.maxLines = cfg.MaxOutputLines
+	b.mu.Unlock()
+}
+
+func (b *Bot) isAllowed(id int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.allowed[id]
+}
+
+// Run получает обновления, пока не отменён ctx
+func (b *Bot) Run(ctx context.Context) {
+	log.Println("Бот: ожидаем команды")
+	for ctx.Err() == nil {
+		if err := b.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			pause := pollPause
+			var apiErr *sender.APIError
+			if errors.As(err, &apiErr) && apiErr.RetryAfter > pause {
+				pause = apiErr.RetryAfter
+			}
+			log.Printf("Бот: ошибка getUpdates, повтор через %s: %v", pause, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pause):
+			}
+		}
+	}
+}
+
+// poll один запрос getUpdates; offset сдвигается сразу, чтобы команда не выполнилась повторно
+func (b *Bot) poll(ctx context.Context) error {
+	var updates []update
+	err := b.client.Call(ctx, "getUpdates", map[string]any{
+		"offset":          b.offset,
+		"timeout":         b.pollTimeout,
+		"allowed_updates": []string{"message", "callback_query"},
+	}, &updates)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		if u.UpdateID >= b.offset {
+			b.offset = u.UpdateID + 1
+		}
+		switch {
+		case u.Message != nil:
+			b.handleMessage(ctx, u.Message)
+		case u.CallbackQuery != nil:
+			b.handleCallback(ctx, u.CallbackQuery)
+		}
+	}
+	return nil
+}
+
+func (b *Bot) handleMessage(ctx context.Context, m *message) {
+	if !strings.HasPrefix(m.Text, "/") || m.From == nil {
+		return
+	}
+
+	if !b.isAllowed(m.From.ID) {
+		log.Printf("Бот: команда %q от пользователя %d (%s) отклонена, его нет в allowed_users", m.Text, m.From.ID, m.From)
+		b.reply(ctx, m.Chat.ID, "⛔ Нет доступа. Попросите добавить ваш id <code>"+itoa(m.From.ID)+"</code> в bot.allowed_users.")
+		return
+	}
+
+	name, args := parseCommand(m.Text)
+	cmd, ok := commands[name]
+	if !ok {
+		b.reply(ctx, m.Chat.ID, "Неизвестная команда "+escape(name)+", список команд: /help")
+		return
+	}
+
+	log.Printf("Бот: команда %s от пользователя %d", name, m.From.ID)
+	if cmd.search != nil {
+		b.startSearch(ctx, m.Chat.ID, cmd.search, args)
+		return
+	}
+	b.reply(ctx, m.Chat.ID, cmd.run(b.backend, m.From, args))
+}
+
+// reply отправляет ответ в HTML; ошибки только логируются, повторять ответ на команду незачем
+func (b *Bot) reply(ctx context.Context, chatID int64, text string) {
+	ctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+
+	err := b.client.Call(ctx, "sendMessage", map[string]any{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}, nil)
+	if err != nil {
+		log.Printf("Бот: ошибка отправки ответа: %v", err)
+	}
+}
+
+// parseCommand "/last@my_bot 5" -> "/last", ["5"]
+func parseCommand(text string) (string, []string) {
+	parts := strings.Fields(text)
+	name := strings.ToLower(parts[0])
+	if i := strings.IndexByte(name, '@'); i >= 0 {
+		name = name[:i]
+	}
+	return name, parts[1:]
+}
@@ -0,0 +1,404 @@
+package bot
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/subscription"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI отдаёт заранее заданные updates на getUpdates и запоминает остальные вызовы
+type fakeAPI struct {
+	mu      sync.Mutex
+	updates []string // JSON-массивы result по очереди
+	offsets []int64
+	replies []map[string]any            // sendMessage
+	calls   map[string][]map[string]any // остальные методы
+}
+
+func (f *fakeAPI) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			f.offsets = append(f.offsets, int64(body["offset"].(float64)))
+			result := "[]"
+			if len(f.updates) > 0 {
+				result, f.updates = f.updates[0], f.updates[1:]
+			}
+			fmt.Fprintf(w, `{"ok":true,"result":%s}`, result)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			f.replies = append(f.replies, body)
+			w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+		default:
+			if f.calls == nil {
+				f.calls = make(map[string][]map[string]any)
+			}
+			method := r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:]
+			f.calls[method] = append(f.calls[method], body)
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type fakeBackend struct {
+	reloads int
+	acked   []string
+	muted   []string
+	subs    []subscription.Subscription
+	logFile string
+}
+
+func (f *fakeBackend) Status() Status {
+	return Status{
+		Started: time.Now().Add(-time.Hour),
+		LogFile: cmp.Or(f.logFile, "app.log"),
+		Offset:  1234,
+		Destinations: []DestinationStatus{
+			{Name: "tg", Type: "telegram", Health: outbox.Health{Sent: 3, Failed: 1, LastError: "ошибка <api>", LastErrorAt: time.Now()}},
+		},
+	}
+}
+
+func (f *fakeBackend) Recent(n int) []Alert {
+	var out []Alert
+	for i := 0; i < n && i < 3; i++ {
+		out = append(out, Alert{Entry: log_processing.LogEntry{Level: "ERROR", Rule: "db", Message: fmt.Sprintf("fail %d <x>", i)}})
+	}
+	return out
+}
+
+func (f *fakeBackend) Ack(id, by string) {
+	f.acked = append(f.acked, id+" "+by)
+}
+
+func (f *fakeBackend) Mute(fingerprint, by string, d time.Duration) (time.Time, error) {
+	f.muted = append(f.muted, fingerprint+" "+by)
+	return time.Now().Add(d), nil
+}
+
+func (f *fakeBackend) Raw(id string) (string, bool) {
+	return "2026-01-01 [ERROR] <raw>", id == "a1"
+}
+
+func (f *fakeBackend) Subscribe(sub subscription.Subscription) (bool, error) {
+	f.subs = append(f.subs, sub)
+	return true, nil
+}
+
+func (f *fakeBackend) Unsubscribe(userID int64, kind, value string) (int, error) {
+	n := len(f.subs)
+	f.subs = nil
+	return n, nil
+}
+
+func (f *fakeBackend) Subscriptions(userID int64) []subscription.Subscription {
+	return f.subs
+}
+
+func (f *fakeBackend) Rules() RulesInfo {
+	return RulesInfo{Rules: []string{"db", "payments"}}
+}
+
+func (f *fakeBackend) Reload() (bool, error) {
+	f.reloads++
+	return true, nil
+}
+
+func updateJSON(id int64, from int64, text string) string {
+	return fmt.Sprintf(`[{"update_id":%d,"message":{"message_id":1,"from":{"id":%d,"username":"u"},"chat":{"id":%d},"text":%q}}]`, id, from, from, text)
+}
+
+func newTestBot(t *testing.T, fake *fakeAPI, be Backend) *Bot {
+	b := New(config.BotConfig{BotToken: "token", AllowedUsers: []int64{42}, PollTimeoutSec: 1}, be)
+	b.SetBaseURL(fake.server(t).URL)
+	return b
+}
+
+func TestBot_PollCommandsAndOffset(t *testing.T) {
+	fake := &fakeAPI{updates: []string{
+		updateJSON(10, 42, "/status"),
+		updateJSON(11, 42, "/last@my_bot 2"),
+		updateJSON(12, 42, "/reload"),
+	}}
+	be := &fakeBackend{}
+	b := newTestBot(t, fake, be)
+
+	for i := 0; i < 4; i++ {
+		if err := b.poll(context.Background()); err != nil {
+			t.Fatalf("ожидается без ошибок, получено: %v", err)
+		}
+	}
+
+	wantOffsets := []int64{0, 11, 12, 13}
+	for i, want := range wantOffsets {
+		if fake.offsets[i] != want {
+			t.Fatalf("offset запроса %d: ожидается %d, получено %d", i, want, fake.offsets[i])
+		}
+	}
+
+	if len(fake.replies) != 3 {
+		t.Fatalf("ожидается 3 ответа, получено %d", len(fake.replies))
+	}
+	status := fake.replies[0]["text"].(string)
+	for _, want := range []string{"1234 байт", "<code>app.log</code>", "отправлено 3", "ошибка &lt;api&gt;"} {
+		if !strings.Contains(status, want) {
+			t.Fatalf("в /status нет %q:\n%s", want, status)
+		}
+	}
+	if fake.replies[0]["parse_mode"] != "HTML" {
+		t.Fatalf("ожидается parse_mode HTML, получено %v", fake.replies[0]["parse_mode"])
+	}
+
+	last := fake.replies[1]["text"].(string)
+	if !strings.Contains(last, "fail 1 &lt;x&gt;") || strings.Contains(last, "fail 2") {
+		t.Fatalf("/last 2 должен вернуть два алерта:\n%s", last)
+	}
+
+	if be.reloads != 1 {
+		t.Fatalf("ожидается одна перезагрузка, получено %d", be.reloads)
+	}
+}
+
+func TestBot_RejectsUnknownUsers(t *testing.T) {
+	fake := &fakeAPI{updates: []string{updateJSON(1, 7, "/reload")}}
+	be := &fakeBackend{}
+	b := newTestBot(t, fake, be)
+
+	if err := b.poll(context.Background()); err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	if be.reloads != 0 {
+		t.Fatal("команда от пользователя не из allowed_users не должна выполняться")
+	}
+	if len(fake.replies) != 1 || !strings.Contains(fake.replies[0]["text"].(string), "Нет доступа") {
+		t.Fatalf("ожидается отказ, получено %v", fake.replies)
+	}
+
+	// после перезагрузки конфига пользователь получил доступ
+	b.Update(config.BotConfig{AllowedUsers: []int64{7}})
+	fake.updates = []string{updateJSON(2, 7, "/reload")}
+	if err := b.poll(context.Background()); err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	if be.reloads != 1 {
+		t.Fatal("после Update пользователь должен получить доступ")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	name, args := parseCommand("/Last@my_bot  5 x")
+	if name != "/last" || len(args) != 2 || args[0] != "5" {
+		t.Fatalf("получено %q %v", name, args)
+	}
+}
+
+type noRulesBackend struct{ fakeBackend }
+
+func (noRulesBackend) Rules() RulesInfo { return RulesInfo{} }
+
+func TestCmdRules_NoRules(t *testing.T) {
+	got := cmdRules(&noRulesBackend{}, nil, nil)
+	if !strings.Contains(got, "алерты не отправляются") {
+		t.Fatalf("без правил бот должен сообщить, что алерты не отправляются, получено: %q", got)
+	}
+}
+
+func callbackJSON(id int64, from int64, data string) string {
+	return fmt.Sprintf(`[{"update_id":%d,"callback_query":{"id":"q%d","from":{"id":%d,"username":"dev"},"data":%q,`+
+		`"message":{"message_id":5,"chat":{"id":-100},"text":"ERROR boom","entities":[{"type":"bold","offset":0,"length":5}],`+
+		`"reply_markup":{"inline_keyboard":[[{"text":"ack","callback_data":"ack:a1"},{"text":"mute","callback_data":"mute:fp"},{"text":"raw","callback_data":"raw:a1"}]]}}}}]`,
+		id, id, from, data)
+}
+
+func TestBot_Callbacks(t *testing.T) {
+	fake := &fakeAPI{updates: []string{
+		callbackJSON(1, 42, "ack:a1"),
+		callbackJSON(2, 42, "mute:fp"),
+		callbackJSON(3, 42, "raw:a1"),
+		callbackJSON(4, 7, "mute:fp"),
+	}}
+	be := &fakeBackend{}
+	b := newTestBot(t, fake, be)
+
+	for i := 0; i < 4; i++ {
+		if err := b.poll(context.Background()); err != nil {
+			t.Fatalf("ожидается без ошибок, получено: %v", err)
+		}
+	}
+
+	if len(be.acked) != 1 || be.acked[0] != "a1 @dev" {
+		t.Fatalf("ожидается Ack a1 от @dev, получено %v", be.acked)
+	}
+	if len(be.muted) != 1 || be.muted[0] != "fp @dev" {
+		t.Fatalf("Mute только от разрешённого пользователя, получено %v", be.muted)
+	}
+
+	edits := fake.calls["editMessageText"]
+	if len(edits) != 2 {
+		t.Fatalf("ожидается два изменения сообщения (Ack и Mute), получено %d", len(edits))
+	}
+	ack := edits[0]
+	if text := ack["text"].(string); !strings.HasPrefix(text, "ERROR boom\n\n✅ Принял @dev") {
+		t.Fatalf("неожиданный текст после Ack: %q", text)
+	}
+	if ack["entities"] == nil || ack["parse_mode"] != nil {
+		t.Fatal("форматирование должно сохраняться через entities")
+	}
+	buttons := ack["reply_markup"].(map[string]any)["inline_keyboard"].([]any)[0].([]any)
+	if len(buttons) != 2 {
+		t.Fatalf("после Ack кнопка «Принял» должна исчезнуть, получено %v", buttons)
+	}
+
+	if len(fake.replies) != 1 || fake.replies[0]["text"] != "<pre>2026-01-01 [ERROR] &lt;raw&gt;</pre>" {
+		t.Fatalf("ожидается исходная строка ответом, получено %v", fake.replies)
+	}
+	if got := len(fake.calls["answerCallbackQuery"]); got != 4 {
+		t.Fatalf("на каждое нажатие нужен answerCallbackQuery, получено %d", got)
+	}
+}
+
+func TestBot_Subscribe(t *testing.T) {
+	fake := &fakeAPI{updates: []string{
+		updateJSON(1, 42, "/subscribe payments"),
+		updateJSON(2, 42, "/subscribe error"),
+		updateJSON(3, 42, "/subscribe app.log"),
+		updateJSON(4, 42, "/subscribe host web-1"),
+		updateJSON(5, 42, "/subscribe"),
+		updateJSON(6, 42, "/unsubscribe"),
+	}}
+	be := &fakeBackend{}
+	b := newTestBot(t, fake, be)
+
+	for i := 0; i < 6; i++ {
+		if err := b.poll(context.Background()); err != nil {
+			t.Fatalf("ожидается без ошибок, получено: %v", err)
+		}
+	}
+
+	want := []string{"rule payments", "level ERROR", "source app.log"}
+	if len(be.subs) != 0 {
+		t.Fatalf("после /unsubscribe без аргументов подписок быть не должно, получено %v", be.subs)
+	}
+	for i, w := range want {
+		if !strings.Contains(fake.replies[i]["text"].(string), "Подписка оформлена: "+strings.Replace(w, " ", " <code>", 1)) {
+			t.Fatalf("ответ %d: ожидается подписка %q, получено %q", i, w, fake.replies[i]["text"])
+		}
+	}
+	if !strings.Contains(fake.replies[3]["text"].(string), "неизвестный тип подписки") {
+		t.Fatalf("ожидается ошибка типа подписки, получено %q", fake.replies[3]["text"])
+	}
+	if list := fake.replies[4]["text"].(string); !strings.Contains(list, "level <code>ERROR</code>") {
+		t.Fatalf("ожидается список подписок, получено %q", list)
+	}
+	if !strings.Contains(fake.replies[5]["text"].(string), "Удалено подписок: 3") {
+		t.Fatalf("ожидается удаление трёх подписок, получено %q", fake.replies[5]["text"])
+	}
+}
+
+func TestBot_TailAndGrep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var sb strings.Builder
+	now := time.Now()
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&sb, "%s [ERROR] db timeout <%d>\n", now.Add(time.Duration(i-300)*time.Second).Format(time.RFC3339), i)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeAPI{updates: []string{
+		updateJSON(1, 42, "/tail 2"),
+		updateJSON(2, 42, "/grep timeout <29\\d> 1h"),
+		updateJSON(3, 42, "/grep timeout"),
+		updateJSON(4, 42, "/grep ( 1h"),
+		updateJSON(5, 7, "/tail 2"),
+	}}
+	b := newTestBot(t, fake, &fakeBackend{logFile: path})
+	b.Update(config.BotConfig{AllowedUsers: []int64{42}, MaxOutputLines: 100})
+
+	for i := 0; i < 5; i++ {
+		if err := b.poll(context.Background()); err != nil {
+			t.Fatalf("ожидается без ошибок, получено: %v", err)
+		}
+		b.searching.Wait() // поиск идёт в горутине, ждём ответ, чтобы порядок был предсказуем
+	}
+
+	if len(fake.replies) != 4 {
+		t.Fatalf("ожидается 4 текстовых ответа (tail, grep, ошибка regex, отказ), получено %d", len(fake.replies))
+	}
+	tail := fake.replies[0]["text"].(string)
+	if !strings.Contains(tail, "<pre>") || !strings.Contains(tail, "&lt;298&gt;\n") || !strings.HasSuffix(tail, "&lt;299&gt;</pre>") {
+		t.Fatalf("неожиданный ответ /tail: %q", tail)
+	}
+	grep := fake.replies[1]["text"].(string)
+	if !strings.Contains(grep, "Совпадений: 10") {
+		t.Fatalf("ожидается 10 совпадений с <29\\d>, получено %q", grep)
+	}
+	if !strings.Contains(fake.replies[2]["text"].(string), "Неверное регулярное выражение") {
+		t.Fatalf("ожидается ошибка regex, получено %q", fake.replies[2]["text"])
+	}
+	if !strings.Contains(fake.replies[3]["text"].(string), "Нет доступа") {
+		t.Fatal("/tail доступен только пользователям из allowed_users")
+	}
+
+	// 300 совпадений длиннее сообщения: последние 100 уходят вложением
+	if n := len(fake.calls["sendDocument"]); n != 1 {
+		t.Fatalf("ожидается одно вложение, получено %d", n)
+	}
+}
+
+func TestBot_SearchDoesNotBlockCommands(t *testing.T) {
+	release := make(chan struct{})
+	commands["/slow"] = command{search: func(context.Context, *Bot, []string) reply {
+		<-release
+		return reply{text: "slow done"}
+	}}
+	t.Cleanup(func() { delete(commands, "/slow") })
+
+	fake := &fakeAPI{updates: []string{
+		updateJSON(1, 42, "/slow"),
+		updateJSON(2, 42, "/slow"),
+		updateJSON(3, 42, "/slow"),
+		updateJSON(4, 42, "/status"),
+	}}
+	b := newTestBot(t, fake, &fakeBackend{})
+
+	for i := 0; i < 4; i++ {
+		if err := b.poll(context.Background()); err != nil {
+			t.Fatalf("ожидается без ошибок, получено: %v", err)
+		}
+	}
+
+	fake.mu.Lock()
+	replies := len(fake.replies)
+	busy := replies > 0 && strings.Contains(fake.replies[0]["text"].(string), "Уже идёт поиск")
+	fake.mu.Unlock()
+	if replies != 2 || !busy {
+		t.Fatalf("пока идут два поиска, ожидается отказ третьему и ответ на /status, получено %d ответов", replies)
+	}
+
+	close(release)
+	b.searching.Wait()
+	if len(fake.replies) != 4 || fake.replies[2]["text"] != "slow done" {
+		t.Fatalf("ожидаются ответы обоих поисков после их окончания, получено %d", len(fake.replies))
+	}
+}
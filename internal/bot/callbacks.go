@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"Bug_tracking_bot/internal/sender"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Нажатия кнопок под алертами (callback_query), кнопки добавляет TelegramSender при telegram.buttons
+
+const (
+	muteDuration = time.Hour
+	rawLimit     = 3500 // символов исходной строки в ответе, остальное обрезается
+)
+
+type callbackQuery struct {
+	ID      string   `json:"id"`
+	From    *user    `json:"from"`
+	Message *message `json:"message"` // нет, если сообщение слишком старое
+	Data    string   `json:"data"`
+}
+
+func (b *Bot) handleCallback(ctx context.Context, q *callbackQuery) {
+	if q.From == nil {
+		return
+	}
+	if !b.isAllowed(q.From.ID) {
+		log.Printf("Бот: нажатие %q от пользователя %d (%s) отклонено, его нет в allowed_users", q.Data, q.From.ID, q.From)
+		b.answerCallback(ctx, q.ID, "⛔ Нет доступа", true)
+		return
+	}
+
+	action, arg, ok := sender.ParseCallback(q.Data)
+	if !ok || arg == "" {
+		b.answerCallback(ctx, q.ID, "Неизвестная кнопка", false)
+		return
+	}
+
+	who := q.From.String()
+	now := time.Now()
+	switch action {
+	case sender.CallbackAck:
+		b.backend.Ack(arg, who)
+		log.Printf("Бот: алерт %s принял %s", arg, who)
+		b.answerCallback(ctx, q.ID, "Принято", false)
+		b.markMessage(ctx, q.Message, fmt.Sprintf("✅ Принял %s в %s", who, now.Format("15:04")), sender.CallbackAck)
+
+	case sender.CallbackMute:
+		until, err := b.backend.Mute(arg, who, muteDuration)
+		if err != nil {
+			log.Printf("Бот: ошибка создания silence для %s: %v", arg, err)
+			b.answerCallback(ctx, q.ID, "Ошибка: "+err.Error(), true)
+			return
+		}
+		log.Printf("Бот: %s заглушил алерты %s до %s", who, arg, until.Format(timeLayout))
+		b.answerCallback(ctx, q.ID, "Заглушено до "+until.Format("15:04"), false)
+		b.markMessage(ctx, q.Message, fmt.Sprintf("🔕 %s заглушил на 1 ч, до %s", who, until.Format("15:04")), sender.CallbackMute)
+
+	case sender.CallbackRaw:
+		raw, found := b.backend.Raw(arg)
+		if !found {
+			b.answerCallback(ctx, q.ID, "Строка больше недоступна: бот хранит только последние алерты с момента запуска", true)
+			return
+		}
+		b.answerCallback(ctx, q.ID, "", false)
+		if q.Message != nil {
+			if r := []rune(raw); len(r) > rawLimit {
+				raw = string(r[:rawLimit]) + "…"
+			}
+			b.replyTo(ctx, q.Message, "<pre>"+escape(raw)+"</pre>")
+		}
+
+	default:
+		b.answerCallback(ctx, q.ID, "Неизвестная кнопка", false)
+	}
+}
+
+// markMessage дописывает строку состояния в конец алерта и убирает нажатую кнопку.
+// Текст приходит без HTML, поэтому отправляем его обратно вместе с entities: смещения
+// разметки не меняются, так как строка добавляется в конец.
+func (b *Bot) markMessage(ctx context.Context, m *message, state, action string) {
+	if m == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+
+	req := map[string]any{
+		"chat_id":                  m.Chat.ID,
+		"message_id":               m.MessageID,
+		"text":                     m.Text + "\n\n" + state,
+		"disable_web_page_preview": true,
+	}
+	if len(m.Entities) > 0 {
+		req["entities"] = m.Entities
+	}
+	kb := m.ReplyMarkup.Without(action)
+	if kb != nil {
+		req["reply_markup"] = kb
+	}
+	if err := b.client.Call(ctx, "editMessageText", req, nil); err != nil {
+		log.Printf("Бот: ошибка изменения сообщения: %v", err)
+		return
+	}
+	// при live_updates отправитель перерисовывает сообщение целиком, отметка не должна потеряться
+	sender.MarkLiveMessage(m.Chat.ID, m.MessageID, state, kb)
+}
+
+func (b *Bot) replyTo(ctx context.Context, m *message, text string) {
+	ctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+
+	err := b.client.Call(ctx, "sendMessage", map[string]any{
+		"chat_id":             m.Chat.ID,
+		"text":                text,
+		"parse_mode":          "HTML",
+		"reply_to_message_id": m.MessageID,
+	}, nil)
+	if err != nil {
+		log.Printf("Бот: ошибка отправки ответа: %v", err)
+	}
+}
+
+// answerCallback убирает «часики» на кнопке; text показывается всплывающим уведомлением
+func (b *Bot) answerCallback(ctx context.Context, id, text string, alert bool) {
+	ctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+
+	req := map[string]any{"callback_query_id": id}
+	if text != "" {
+		req["text"] = text
+		req["show_alert"] = alert
+	}
+	if err := b.client.Call(ctx, "answerCallbackQuery", req, nil); err != nil {
+		log.Printf("Бот: ошибка ответа на нажатие: %v", err)
+	}
+}
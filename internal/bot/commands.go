@@ -0,0 +1,219 @@
+package bot
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	logproc "Bug_tracking_bot/internal/log_processing/formatter"
+	"Bug_tracking_bot/internal/outbox"
+	"Bug_tracking_bot/internal/subscription"
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLast  = 5
+	maxLast      = 20
+	lastMsgLimit = 120 // символов сообщения в /last, чтобы ответ уложился в 4096
+	timeLayout   = "2006-01-02 15:04:05"
+)
+
+// Backend данные и действия бота; вызовы выполняются в основном цикле, см. cmd
+type Backend interface {
+	Status() Status
+	Recent(n int) []Alert // последние отправленные алерты, новые первыми
+	Rules() RulesInfo
+	Reload() (bool, error) // принудительная перезагрузка конфига; false - конфиг не применён
+
+	// Действия кнопок под алертом, см. callbacks.go
+	Ack(id, by string)
+	Mute(fingerprint, by string, d time.Duration) (time.Time, error) // возвращает время окончания silence
+	Raw(id string) (string, bool)
+
+	// Подписки, см. subscriptions.go
+	Subscribe(sub subscription.Subscription) (bool, error)
+	Unsubscribe(userID int64, kind, value string) (int, error) // пустой kind - все подписки
+	Subscriptions(userID int64) []subscription.Subscription
+}
+
+// Alert отправленный алерт и кто его принял кнопкой
+type Alert struct {
+	Entry   log_processing.LogEntry
+	AckedBy string
+	AckedAt time.Time
+}
+
+// Status состояние для /status
+type Status struct {
+	Started      time.Time
+	LogFile      string
+	Offset       int64 // прочитано байт файла
+	LastAlert    *log_processing.LogEntry
+	Destinations []DestinationStatus
+}
+
+type DestinationStatus struct {
+	Name   string
+	Type   string
+	Health outbox.Health
+}
+
+// RulesInfo активные правила для /rules
+type RulesInfo struct {
+	Levels []string // общий фильтр по уровням; пусто = все
+	Rules  []string
+}
+
+type command struct {
+	help string
+	run  func(be Backend, from *user, args []string) string
+	// search команды с длинным ответом (см. search.go): результат может прийти вложением
+	search func(ctx context.Context, b *Bot, args []string) reply
+}
+
+var commands map[string]command
+
+func init() {
+	// init, потому что /help обращается к самой таблице
+	commands = map[string]command{
+		"/status": {help: "состояние: аптайм, позиция в файле, последний алерт, доставка", run: cmdStatus},
+		"/last":   {help: "/last N - последние N алертов (по умолчанию 5, не больше 20)", run: cmdLast},
+		"/rules":  {help: "активные правила", run: cmdRules},
+		"/reload": {help: "перечитать config.yaml", run: cmdReload},
+		"/tail":   {help: "/tail N - последние N строк лога", search: cmdTail},
+		"/grep":   {help: "/grep regex [30m|2h|7d] - строки лога за период (по умолчанию 1h), включая ротированные файлы", search: cmdGrep},
+
+		"/subscribe":   {help: "/subscribe [rule|level|source] значение - получать такие алерты в личку; без аргументов - мои подписки", run: cmdSubscribe},
+		"/unsubscribe": {help: "/unsubscribe [rule|level|source] значение - отписаться; без аргументов - от всего", run: cmdUnsubscribe},
+		"/help":        {help: "список команд", run: cmdHelp},
+		"/start":       {help: "", run: cmdHelp},
+	}
+}
+
+func cmdHelp(Backend, *user, []string) string {
+	names := make([]string, 0, len(commands))
+	for name, c := range commands {
+		if c.help != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("<b>Команды:</b>\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s — %s\n", name, escape(commands[name].help))
+	}
+	return sb.String()
+}
+
+func cmdStatus(be Backend, _ *user, _ []string) string {
+	st := be.Status()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>Аптайм:</b> %s\n", time.Since(st.Started).Round(time.Second))
+	fmt.Fprintf(&sb, "<b>Файл:</b> <code>%s</code>\n", escape(st.LogFile))
+	fmt.Fprintf(&sb, "<b>Прочитано:</b> %d байт\n", st.Offset)
+	if st.LastAlert != nil {
+		fmt.Fprintf(&sb, "<b>Последний алерт:</b> %s\n", formatEntryLine(*st.LastAlert))
+	} else {
+		sb.WriteString("<b>Последний алерт:</b> не было\n")
+	}
+
+	sb.WriteString("\n<b>Доставка:</b>\n")
+	for _, d := range st.Destinations {
+		h := d.Health
+		icon := "✅"
+		if h.LastErrorAt.After(h.LastSuccess) {
+			icon = "⚠️"
+		}
+		fmt.Fprintf(&sb, "%s <b>%s</b> (%s): в очереди %d, отправлено %d, ошибок %d, удалено %d\n",
+			icon, escape(d.Name), escape(d.Type), h.Pending, h.Sent, h.Failed, h.Dropped)
+		if h.LastError != "" {
+			fmt.Fprintf(&sb, "    последняя ошибка %s: <code>%s</code>\n", h.LastErrorAt.Format(timeLayout), escape(h.LastError))
+		}
+	}
+	return sb.String()
+}
+
+func cmdLast(be Backend, _ *user, args []string) string {
+	n := defaultLast
+	if len(args) > 0 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v <= 0 {
+			return "Использование: /last N, где N - число от 1 до " + strconv.Itoa(maxLast)
+		}
+		n = min(v, maxLast)
+	}
+
+	entries := be.Recent(n)
+	if len(entries) == 0 {
+		return "Алертов с момента запуска не было"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<b>Последние алерты (%d):</b>\n", len(entries))
+	for _, a := range entries {
+		sb.WriteString(formatEntryLine(a.Entry))
+		if a.AckedBy != "" {
+			fmt.Fprintf(&sb, " — ✅ %s", escape(a.AckedBy))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func cmdRules(be Backend, _ *user, _ []string) string {
+	info := be.Rules()
+
+	var sb strings.Builder
+	if len(info.Levels) > 0 {
+		fmt.Fprintf(&sb, "<b>Уровни:</b> %s\n", escape(strings.Join(info.Levels, ", ")))
+	} else {
+		sb.WriteString("<b>Уровни:</b> все\n")
+	}
+	if len(info.Rules) == 0 {
+		sb.WriteString("Правил нет, алерты не отправляются")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "<b>Правила (%d):</b>\n", len(info.Rules))
+	for _, r := range info.Rules {
+		fmt.Fprintf(&sb, "• <code>%s</code>\n", escape(r))
+	}
+	return sb.String()
+}
+
+func cmdReload(be Backend, _ *user, _ []string) string {
+	applied, err := be.Reload()
+	switch {
+	case err != nil:
+		return "❌ Ошибка перезагрузки: <code>" + escape(err.Error()) + "</code>"
+	case !applied:
+		return "❌ Конфиг не применён, подробности в логе бота"
+	}
+	return "✅ Конфиг перезагружен"
+}
+
+// formatEntryLine одна строка об алерте: значок, время, правило, начало сообщения
+func formatEntryLine(e log_processing.LogEntry) string {
+	s := logproc.LevelEmoji(e.Level) + " " + e.Timestamp.Format(timeLayout) + " <b>" + escape(e.Level) + "</b>"
+	if e.Rule != "" {
+		s += " [" + escape(e.Rule) + "]"
+	}
+	msg := e.Message
+	if r := []rune(msg); len(r) > lastMsgLimit {
+		msg = string(r[:lastMsgLimit-1]) + "…"
+	}
+	return s + " " + escape(msg)
+}
+
+func escape(s string) string {
+	return html.EscapeString(s)
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
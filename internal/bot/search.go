@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"Bug_tracking_bot/internal/logsearch"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Команды /tail и /grep по файлу лога. Короткий результат приходит сообщением, длинный - вложением .txt.
+
+const (
+	defaultTail   = 20
+	defaultSince  = "1h"
+	inlineLimit   = 3500 // символов результата, которые помещаются в сообщение вместе с заголовком
+	searchTimeout = 30 * time.Second
+	maxPattern    = 200
+	maxSearches   = 2 // одновременных /tail и /grep; остальные получают отказ, а не ждут
+)
+
+// reply ответ с необязательным вложением
+type reply struct {
+	text     string // HTML; при вложении - подпись
+	document []byte
+	filename string
+}
+
+func (b *Bot) limit() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.maxLines
+}
+
+func cmdTail(_ context.Context, b *Bot, args []string) reply {
+	n := defaultTail
+	if len(args) > 0 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v <= 0 {
+			return reply{text: "Использование: /tail N"}
+		}
+		n = v
+	}
+	limited := n > b.limit()
+	n = min(n, b.limit())
+
+	path := b.backend.Status().LogFile
+	res, err := logsearch.Tail(path, n)
+	if err != nil {
+		return reply{text: "❌ " + escape(err.Error())}
+	}
+
+	header := fmt.Sprintf("<b>Последние %d строк</b> <code>%s</code>", len(res.Lines), escape(path))
+	if limited {
+		header += fmt.Sprintf(" (не больше %d)", n)
+	}
+	return resultReply(header, res.Lines, "tail.txt")
+}
+
+// cmdGrep /grep regex [since]: последний аргумент считается окном, если разбирается как 30m/2h/7d
+func cmdGrep(ctx context.Context, b *Bot, args []string) reply {
+	usage := reply{text: "Использование: /grep regex [30m|2h|7d], например /grep \"db timeout\" 2h"}
+	if len(args) == 0 {
+		return usage
+	}
+
+	now := time.Now()
+	sinceArg := defaultSince
+	if len(args) > 1 {
+		if _, err := logsearch.ParseSince(args[len(args)-1], now); err == nil {
+			sinceArg = args[len(args)-1]
+			args = args[:len(args)-1]
+		}
+	}
+	since, _ := logsearch.ParseSince(sinceArg, now)
+
+	pattern := strings.Trim(strings.Join(args, " "), `"`)
+	if pattern == "" || len(pattern) > maxPattern {
+		return usage
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return reply{text: "❌ Неверное регулярное выражение: <code>" + escape(err.Error()) + "</code>"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	path := b.backend.Status().LogFile
+	res, err := logsearch.Grep(ctx, path, re, since, b.limit())
+	if err != nil {
+		log.Printf("Бот: ошибка /grep %q: %v", pattern, err)
+		return reply{text: "❌ " + escape(err.Error())}
+	}
+	if res.Total == 0 {
+		return reply{text: fmt.Sprintf("Совпадений с <code>%s</code> за %s нет (файлов просмотрено: %d)", escape(pattern), sinceArg, res.Files)}
+	}
+
+	header := fmt.Sprintf("<b>Совпадений: %d</b> с <code>%s</code> за %s", res.Total, escape(pattern), sinceArg)
+	if res.Total > len(res.Lines) {
+		header += fmt.Sprintf(", показаны последние %d", len(res.Lines))
+	}
+	return resultReply(header, res.Lines, "grep.txt")
+}
+
+// startSearch выполняет поиск в отдельной горутине: /grep по ротированным файлам идёт до searchTimeout,
+// и в цикле getUpdates он задержал бы все остальные команды и кнопки
+func (b *Bot) startSearch(ctx context.Context, chatID int64, search func(context.Context, *Bot, []string) reply, args []string) {
+	select {
+	case b.searches <- struct{}{}:
+	default:
+		b.reply(ctx, chatID, "⏳ Уже идёт поиск, повторите команду позже")
+		return
+	}
+	b.searching.Add(1)
+	go func() {
+		defer b.searching.Done()
+		defer func() { <-b.searches }()
+		b.sendReply(ctx, chatID, search(ctx, b, args))
+	}()
+}
+
+// resultReply короткий результат в <pre>, длинный - вложением
+func resultReply(header string, lines []string, filename string) reply {
+	if len(lines) == 0 {
+		return reply{text: header + "\nФайл пуст"}
+	}
+	body := strings.Join(lines, "\n")
+	if len([]rune(body)) <= inlineLimit {
+		return reply{text: header + "\n<pre>" + escape(body) + "</pre>"}
+	}
+	return reply{text: header, document: []byte(body + "\n"), filename: filename}
+}
+
+// sendReply отправляет ответ сообщением или вложением с подписью
+func (b *Bot) sendReply(ctx context.Context, chatID int64, r reply) {
+	if r.document == nil {
+		b.reply(ctx, chatID, r.text)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+
+	// подпись - короткий заголовок, в лимит 1024 символа она укладывается
+	fields := map[string]string{
+		"chat_id":    strconv.FormatInt(chatID, 10),
+		"caption":    r.text,
+		"parse_mode": "HTML",
+	}
+	if err := b.client.Upload(ctx, "sendDocument", fields, "document", r.filename, r.document, nil); err != nil {
+		log.Printf("Бот: ошибка отправки вложения: %v", err)
+	}
+}
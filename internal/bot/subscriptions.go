@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"Bug_tracking_bot/internal/subscription"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Команды /subscribe и /unsubscribe: подписанный пользователь получает подходящие алерты в личку
+
+var knownLevels = parser.Levels
+
+const subscribeUsage = "Использование: /subscribe [rule|level|source] значение, например /subscribe payments или /subscribe level ERROR"
+
+func cmdSubscribe(be Backend, from *user, args []string) string {
+	if len(args) == 0 {
+		return listSubscriptions(be.Subscriptions(from.ID)) + "\n" + escape(subscribeUsage)
+	}
+
+	kind, value, err := parseSubscription(be, args)
+	if err != nil {
+		return escape(err.Error())
+	}
+
+	added, err := be.Subscribe(subscription.Subscription{
+		UserID:    from.ID,
+		User:      from.String(),
+		Kind:      kind,
+		Value:     value,
+		CreatedAt: time.Now(),
+	})
+	switch {
+	case err != nil:
+		return "❌ Ошибка: <code>" + escape(err.Error()) + "</code>"
+	case !added:
+		return fmt.Sprintf("Вы уже подписаны: %s <code>%s</code>", kind, escape(value))
+	}
+	return fmt.Sprintf("✅ Подписка оформлена: %s <code>%s</code>\n"+
+		"Такие алерты будут приходить в личные сообщения. Если вы ещё не писали боту в личку, напишите ему /start, иначе Telegram не даст ему написать первым.",
+		kind, escape(value))
+}
+
+func cmdUnsubscribe(be Backend, from *user, args []string) string {
+	var kind, value string
+	if len(args) > 0 {
+		var err error
+		if kind, value, err = parseSubscription(be, args); err != nil {
+			return escape(err.Error())
+		}
+	}
+
+	n, err := be.Unsubscribe(from.ID, kind, value)
+	switch {
+	case err != nil:
+		return "❌ Ошибка: <code>" + escape(err.Error()) + "</code>"
+	case n == 0:
+		return "Такой подписки нет. " + listSubscriptions(be.Subscriptions(from.ID))
+	}
+	return fmt.Sprintf("✅ Удалено подписок: %d", n)
+}
+
+// parseSubscription "rule payments", "level error" или одно значение: тип угадывается -
+// имя правила, затем уровень, иначе источник (файл лога)
+func parseSubscription(be Backend, args []string) (string, string, error) {
+	if len(args) >= 2 {
+		kind := strings.ToLower(args[0])
+		value := strings.Join(args[1:], " ")
+		switch kind {
+		case subscription.KindRule, subscription.KindSource:
+			return kind, value, nil
+		case subscription.KindLevel:
+			return kind, strings.ToUpper(value), nil
+		}
+		return "", "", fmt.Errorf("неизвестный тип подписки %q. %s", args[0], subscribeUsage)
+	}
+
+	value := args[0]
+	if slices.Contains(be.Rules().Rules, value) {
+		return subscription.KindRule, value, nil
+	}
+	if slices.Contains(knownLevels, strings.ToUpper(value)) {
+		return subscription.KindLevel, strings.ToUpper(value), nil
+	}
+	return subscription.KindSource, value, nil
+}
+
+func listSubscriptions(subs []subscription.Subscription) string {
+	if len(subs) == 0 {
+		return "Подписок нет."
+	}
+	var sb strings.Builder
+	sb.WriteString("<b>Ваши подписки:</b>\n")
+	for _, s := range subs {
+		fmt.Fprintf(&sb, "• %s <code>%s</code>\n", s.Kind, escape(s.Value))
+	}
+	return sb.String()
+}
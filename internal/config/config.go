@@ -4,38 +4,199 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"text/template"
 
 	"gopkg.in/yaml.v3"
 )
 
-const defaultPollIntervalMS = 500 // Частота чтения логов
+const (
+	defaultPollIntervalMS = 500             // Частота чтения логов
+	defaultSilencesFile   = "silences.json" // Файл с silences по умолчанию
+
+	// Повтор отправки по умолчанию
+	defaultRetryMaxAttempts     = 5
+	defaultRetryInitialInterval = 500
+	defaultRetryMaxInterval     = 30_000
+	defaultRetryMultiplier      = 2
+	defaultRetryJitter          = 0.2
+	defaultRetryMaxElapsed      = 120_000
+
+	defaultOutboxFile          = "outbox.jsonl"
+	defaultOutboxMaxAgeSec     = 3600
+	defaultOutboxRetryInterval = 5000
+
+	defaultTelegramDocumentThreshold = 12000
+	defaultBotPollTimeoutSec         = 30
+	defaultLiveUpdateIntervalSec     = 30
+	defaultSubscriptionsFile         = "subscriptions.json"
+	defaultBotMaxOutputLines         = 500
+	defaultReportAt                  = "09:00"
+	defaultReportStatsFile           = "report_stats.json"
+)
 
 type Config struct {
-	LogFile        string         `yaml:"log_file"`
-	PollIntervalMS int            `yaml:"poll_interval_ms"`
-	Sender         Sender         `yaml:"sender"`
-	Telegram       TelegramConfig `yaml:"telegram"`
-	Filters        FiltersConfig  `yaml:"filters"`
-	Format         FormatConfig   `yaml:"format"`
+	LogFile        string          `yaml:"log_file"`
+	PollIntervalMS int             `yaml:"poll_interval_ms"`
+	Sender         Sender          `yaml:"sender"`
+	Telegram       TelegramConfig  `yaml:"telegram"`
+	Filters        FiltersConfig   `yaml:"filters"`
+	Format         FormatConfig    `yaml:"format"`
+	Dedup          DedupConfig     `yaml:"dedup"`
+	Silences       SilencesConfig  `yaml:"silences"`
+	Schedules      SchedulesConfig `yaml:"schedules"`
+	Retry          RetryConfig     `yaml:"retry"`
+	Outbox         OutboxConfig    `yaml:"outbox"`
+
+	// Несколько получателей и маршрутизация; если destinations пусто,
+	// используется единственный получатель из sender/telegram/format (имя default)
+	Destinations map[string]DestinationConfig `yaml:"destinations"`
+	Routes       []RouteConfig                `yaml:"routes"`
+	Unrouted     []string                     `yaml:"unrouted"` // получатели алертов, не подошедших ни под один маршрут; пусто = не отправлять
+
+	Bot    BotConfig    `yaml:"bot"`
+	Report ReportConfig `yaml:"report"`
 }
 
 type Sender struct {
-	Type string `yaml:"type"` // stdout | telegram
+	Type string `yaml:"type"` // stdout | telegram; не используется, если заданы destinations
 }
 
 type TelegramConfig struct {
-	BotToken string `yaml:"bot_token"`
-	ChatID   string `yaml:"chat_id"`
+	BotToken     string               `yaml:"bot_token"`
+	ChatID       string               `yaml:"chat_id"`
+	ThreadID     int                  `yaml:"message_thread_id"` // тема форума для chat_id
+	Chats        []TelegramChatConfig `yaml:"chats"`             // дополнительные чаты со своими условиями
+	SilentLevels []string             `yaml:"silent_levels"`     // уровни без звука (disable_notification); не задано = DEBUG, INFO
+
+	// Сообщения длиннее 4096 символов режутся на части; длиннее document_threshold
+	// отправляются кратким началом и полным текстом во вложении .txt. 0 = 12000, -1 = всегда резать
+	DocumentThreshold int `yaml:"document_threshold"`
+
+	// Кнопки «Принял», «Mute 1h», «Исходная строка» под алертом; нажатия обрабатывает бот (секция bot)
+	Buttons bool `yaml:"buttons"`
+
+	// Повторы алерта в окне дедупликации не подавляются молча, а дописываются в первое сообщение:
+	// «повторялось N раз, последний в HH:MM». Сообщение редактируется не чаще live_update_interval_sec (по умолчанию 30)
+	LiveUpdates           bool `yaml:"live_updates"`
+	LiveUpdateIntervalSec int  `yaml:"live_update_interval_sec"`
+}
+
+// TelegramChatConfig чат (или тема форума), в который уходят только подходящие алерты
+type TelegramChatConfig struct {
+	ChatID       string   `yaml:"chat_id"`
+	ThreadID     int      `yaml:"message_thread_id"`
+	Levels       []string `yaml:"levels"`        // пусто = любые
+	Rules        []string `yaml:"rules"`         // пусто = любые
+	SilentLevels []string `yaml:"silent_levels"` // не задано = как у получателя
 }
 
 type FiltersConfig struct {
-	Levels     []string `yaml:"levels"`      // Если пустой, значит все 3 уровня
-	AlertRegex []string `yaml:"alert_regex"` // Обязательные регулярные выражения для отбора логов
+	Levels     []string     `yaml:"levels"`      // Если пустой, значит все 3 уровня
+	AlertRegex []string     `yaml:"alert_regex"` // Регулярные выражения для отбора логов, имя правила = сам regex
+	Rules      []RuleConfig `yaml:"rules"`       // Именованные правила с типизированными шаблонами
+}
+
+// RuleConfig правило срабатывает, если совпал любой из его шаблонов
+type RuleConfig struct {
+	Name     string          `yaml:"name"`
+	Levels   []string        `yaml:"levels"` // Дополнительный фильтр по уровню для правила
+	Patterns []PatternConfig `yaml:"patterns"`
+}
+
+type PatternConfig struct {
+	Type       string `yaml:"type"` // regex | iregex | literal | prefix | keywords_file
+	Value      string `yaml:"value"`
+	IgnoreCase bool   `yaml:"ignore_case"`
 }
 
 type FormatConfig struct {
-	IncludeRaw         bool `yaml:"include_raw"`
-	IncludeFingerprint bool `yaml:"include_fingerprint"`
+	IncludeRaw         bool   `yaml:"include_raw"`
+	IncludeFingerprint bool   `yaml:"include_fingerprint"`
+	MessageTemplate    string `yaml:"message_template"` // text/template над LogEntry, например "order {{.Fields.order_id}} failed"
+
+	message *template.Template // разобранный message_template, заполняется в Validate
+}
+
+// Template разобранный message_template; nil, если шаблон не задан или конфиг не прошёл Validate
+func (f FormatConfig) Template() *template.Template {
+	return f.message
+}
+
+// compile разбирает message_template один раз, чтобы не делать этого на каждое сообщение
+func (f *FormatConfig) compile() error {
+	if f.MessageTemplate == "" {
+		return nil
+	}
+	tmpl, err := template.New("message").Option("missingkey=zero").Parse(f.MessageTemplate)
+	if err != nil {
+		return err
+	}
+	f.message = tmpl
+	return nil
+}
+
+type DedupConfig struct {
+	GroupBy []string `yaml:"group_by"` // Поля из именованных групп regex; если пусто, ключ считается по исходной строке
+}
+
+type SilencesConfig struct {
+	File string `yaml:"file"` // JSON-файл с silences, переживает перезапуск
+}
+
+type SchedulesConfig struct {
+	Timezone string           `yaml:"timezone"` // IANA, например Europe/Moscow; пусто = локальное время
+	Windows  []ScheduleWindow `yaml:"windows"`
+}
+
+// ScheduleWindow окно тишины или обслуживания. Применяется первое подходящее окно.
+type ScheduleWindow struct {
+	Name         string   `yaml:"name"`
+	Days         []string `yaml:"days"`          // mon..sun; пусто = каждый день
+	From         string   `yaml:"from"`          // HH:MM
+	To           string   `yaml:"to"`            // HH:MM; если меньше from, окно переходит через полночь
+	Levels       []string `yaml:"levels"`        // к каким уровням применяется; пусто = ко всем
+	ExceptLevels []string `yaml:"except_levels"` // какие уровни окно не трогает
+	Rules        []string `yaml:"rules"`         // к каким правилам применяется; пусто = ко всем
+	Action       string   `yaml:"action"`        // drop | delay | downgrade
+	DowngradeTo  string   `yaml:"downgrade_to"`  // уровень для downgrade, по умолчанию INFO
+}
+
+// RetryConfig повтор отправки при временных ошибках (сеть, 429, 5xx)
+type RetryConfig struct {
+	MaxAttempts       int     `yaml:"max_attempts"`        // 1 = без повторов
+	InitialIntervalMS int     `yaml:"initial_interval_ms"` // задержка перед второй попыткой
+	MaxIntervalMS     int     `yaml:"max_interval_ms"`     // верхняя граница задержки
+	Multiplier        float64 `yaml:"multiplier"`          // во сколько раз растёт задержка
+	Jitter            float64 `yaml:"jitter"`              // случайное отклонение задержки, доля от 0 до 1; 0 = 0.2, -1 = без отклонения
+	MaxElapsedMS      int     `yaml:"max_elapsed_ms"`      // общее время на все попытки; 0 = 120000, -1 = без ограничения
+}
+
+// BotConfig интерактивные команды в Telegram через getUpdates
+type BotConfig struct {
+	Enabled        bool    `yaml:"enabled"`
+	BotToken       string  `yaml:"bot_token"`        // по умолчанию telegram.bot_token или токен первого получателя telegram; применяется только при старте
+	AllowedUsers   []int64 `yaml:"allowed_users"`    // Telegram user id, которым разрешены команды
+	PollTimeoutSec int     `yaml:"poll_timeout_sec"` // таймаут long polling, по умолчанию 30
+
+	SubscriptionsFile string `yaml:"subscriptions_file"` // подписки /subscribe, по умолчанию subscriptions.json
+	MaxOutputLines    int    `yaml:"max_output_lines"`   // больше строк /tail и /grep не возвращают, по умолчанию 500
+}
+
+// ReportConfig регулярный отчёт о состоянии логов и статистике алертов; время - в часовом поясе schedules.timezone
+type ReportConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Every        string   `yaml:"every"`        // daily | weekly, по умолчанию daily
+	At           string   `yaml:"at"`           // HH:MM, по умолчанию 09:00
+	Weekday      string   `yaml:"weekday"`      // день для weekly: mon..sun, по умолчанию mon
+	Destinations []string `yaml:"destinations"` // пусто = все получатели
+	StatsFile    string   `yaml:"stats_file"`   // счётчики между отчётами, по умолчанию report_stats.json
+}
+
+// OutboxConfig локальная очередь сообщений между formatter и sender
+type OutboxConfig struct {
+	File            string `yaml:"file"`              // журнал очереди (JSONL); путь применяется только при старте
+	MaxAgeSec       int    `yaml:"max_age_sec"`       // старше - удаляем без отправки; -1 = без ограничения
+	RetryIntervalMS int    `yaml:"retry_interval_ms"` // пауза перед новой попыткой доставки
 }
 
 func Load(path string) (*Config, error) {
@@ -64,19 +225,41 @@ func (c *Config) Validate() error {
 		c.PollIntervalMS = defaultPollIntervalMS
 	}
 
-	c.Sender.Type = strings.ToLower(strings.TrimSpace(c.Sender.Type))
-	switch c.Sender.Type {
-	case "stdout", "telegram":
-	default:
-		return fmt.Errorf("config: тип отправителя должен быть stdout|telegram")
+	c.Silences.File = strings.TrimSpace(c.Silences.File)
+	if c.Silences.File == "" {
+		c.Silences.File = defaultSilencesFile
+	}
+
+	for i, w := range c.Schedules.Windows {
+		if strings.TrimSpace(w.Name) == "" {
+			c.Schedules.Windows[i].Name = fmt.Sprintf("window-%d", i+1)
+		}
+		if strings.TrimSpace(w.From) == "" || strings.TrimSpace(w.To) == "" {
+			return fmt.Errorf("schedules.windows[%d]: from и to обязательны", i)
+		}
+	}
+
+	if err := c.Retry.normalize(); err != nil {
+		return err
+	}
+
+	c.Outbox.File = strings.TrimSpace(c.Outbox.File)
+	if c.Outbox.File == "" {
+		c.Outbox.File = defaultOutboxFile
+	}
+	if c.Outbox.MaxAgeSec == 0 {
+		c.Outbox.MaxAgeSec = defaultOutboxMaxAgeSec
+	}
+	if c.Outbox.RetryIntervalMS <= 0 {
+		c.Outbox.RetryIntervalMS = defaultOutboxRetryInterval
 	}
 
 	for i := range c.Filters.Levels {
 		c.Filters.Levels[i] = strings.ToUpper(strings.TrimSpace(c.Filters.Levels[i]))
 	}
 
-	if len(c.Filters.AlertRegex) == 0 {
-		return fmt.Errorf("filters.alert_regex не может быть пустым")
+	if len(c.Filters.AlertRegex) == 0 && len(c.Filters.Rules) == 0 {
+		return fmt.Errorf("filters.alert_regex и filters.rules не могут быть пустыми одновременно")
 	}
 
 	for _, mes := range c.Filters.AlertRegex {
@@ -85,11 +268,174 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	if c.Sender.Type == "telegram" {
-		if c.Telegram.BotToken == "" || c.Telegram.ChatID == "" {
-			return fmt.Errorf("config: отсутствует токен телеграм бота и ChatId")
+	ruleNames := make(map[string]struct{}, len(c.Filters.Rules))
+	for i := range c.Filters.Rules {
+		r := &c.Filters.Rules[i]
+		r.Name = strings.TrimSpace(r.Name)
+		if r.Name == "" {
+			return fmt.Errorf("filters.rules[%d]: не задано имя правила", i)
+		}
+		if _, ok := ruleNames[r.Name]; ok {
+			return fmt.Errorf("filters.rules: повторяющееся имя правила %q", r.Name)
+		}
+		ruleNames[r.Name] = struct{}{}
+
+		for j := range r.Levels {
+			r.Levels[j] = strings.ToUpper(strings.TrimSpace(r.Levels[j]))
+		}
+
+		if len(r.Patterns) == 0 {
+			return fmt.Errorf("правило %q: patterns не может быть пустым", r.Name)
+		}
+		for j := range r.Patterns {
+			p := &r.Patterns[j]
+			p.Type = strings.ToLower(strings.TrimSpace(p.Type))
+			if p.Type == "" {
+				p.Type = "regex"
+			}
+			switch p.Type {
+			case "regex", "iregex", "literal", "prefix", "keywords_file":
+			default:
+				return fmt.Errorf("правило %q: тип шаблона должен быть regex|iregex|literal|prefix|keywords_file", r.Name)
+			}
+			if p.Value == "" {
+				return fmt.Errorf("правило %q: пустое значение шаблона", r.Name)
+			}
+		}
+	}
+
+	if err := c.Format.compile(); err != nil {
+		return fmt.Errorf("format.message_template: %w", err)
+	}
+
+	for i := range c.Dedup.GroupBy {
+		c.Dedup.GroupBy[i] = strings.TrimSpace(c.Dedup.GroupBy[i])
+		if c.Dedup.GroupBy[i] == "" {
+			return fmt.Errorf("dedup.group_by не может содержать пустые значения")
 		}
 	}
 
+	if err := c.validateDestinations(); err != nil {
+		return err
+	}
+
+	if err := c.validateBot(); err != nil {
+		return err
+	}
+
+	if err := c.validateReport(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// normalize подставляет значения по умолчанию для незаданных параметров повтора.
+// 0 в yaml не отличить от незаданного значения, поэтому отключаются jitter и max_elapsed_ms через -1.
+func (r *RetryConfig) normalize() error {
+	if r.MaxAttempts < 0 || r.InitialIntervalMS < 0 || r.MaxIntervalMS < 0 || r.MaxElapsedMS < -1 || r.Multiplier < 0 {
+		return fmt.Errorf("retry: параметры не могут быть отрицательными (кроме max_elapsed_ms: -1)")
+	}
+	if r.Jitter != -1 && (r.Jitter < 0 || r.Jitter > 1) {
+		return fmt.Errorf("retry.jitter должен быть от 0 до 1 или -1")
+	}
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if r.InitialIntervalMS == 0 {
+		r.InitialIntervalMS = defaultRetryInitialInterval
+	}
+	if r.MaxIntervalMS == 0 {
+		r.MaxIntervalMS = defaultRetryMaxInterval
+	}
+	if r.Multiplier == 0 {
+		r.Multiplier = defaultRetryMultiplier
+	}
+	if r.Jitter == 0 {
+		r.Jitter = defaultRetryJitter
+	}
+	if r.MaxElapsedMS == 0 {
+		r.MaxElapsedMS = defaultRetryMaxElapsed
+	}
+	return nil
+}
+
+func (c *Config) validateBot() error {
+	if !c.Bot.Enabled {
+		for _, name := range c.DestinationNames() {
+			if d := c.Destinations[name]; d.Type == "telegram" && d.Telegram.Buttons {
+				return fmt.Errorf("destinations.%s: telegram.buttons требует bot.enabled, иначе нажатия некому обрабатывать", name)
+			}
+		}
+		return nil
+	}
+	if c.Bot.BotToken == "" {
+		c.Bot.BotToken = c.Telegram.BotToken
+	}
+	if c.Bot.BotToken == "" {
+		for _, name := range c.DestinationNames() {
+			if d := c.Destinations[name]; d.Type == "telegram" {
+				c.Bot.BotToken = d.Telegram.BotToken
+				break
+			}
+		}
+	}
+	if c.Bot.BotToken == "" {
+		return fmt.Errorf("bot: не задан bot_token и нет получателя telegram, у которого его можно взять")
+	}
+	if len(c.Bot.AllowedUsers) == 0 {
+		return fmt.Errorf("bot: allowed_users не может быть пустым, иначе командами сможет пользоваться кто угодно")
+	}
+	if c.Bot.PollTimeoutSec <= 0 {
+		c.Bot.PollTimeoutSec = defaultBotPollTimeoutSec
+	}
+	if c.Bot.MaxOutputLines <= 0 {
+		c.Bot.MaxOutputLines = defaultBotMaxOutputLines
+	}
+	c.Bot.SubscriptionsFile = strings.TrimSpace(c.Bot.SubscriptionsFile)
+	if c.Bot.SubscriptionsFile == "" {
+		c.Bot.SubscriptionsFile = defaultSubscriptionsFile
+	}
+	for _, name := range c.DestinationNames() {
+		d := c.Destinations[name]
+		if d.Type == "telegram" && d.Telegram.Buttons && d.Telegram.BotToken != c.Bot.BotToken {
+			// нажатия приходят тому боту, который отправил сообщение
+			return fmt.Errorf("destinations.%s: telegram.buttons работают, только если bot_token получателя совпадает с bot.bot_token", name)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateReport() error {
+	r := &c.Report
+	if !r.Enabled {
+		return nil
+	}
+	r.Every = strings.ToLower(strings.TrimSpace(r.Every))
+	if r.Every == "" {
+		r.Every = "daily"
+	}
+	if r.Every != "daily" && r.Every != "weekly" {
+		return fmt.Errorf("report.every должен быть daily|weekly")
+	}
+	if strings.TrimSpace(r.At) == "" {
+		r.At = defaultReportAt
+	}
+	if r.Every == "weekly" && strings.TrimSpace(r.Weekday) == "" {
+		r.Weekday = "mon"
+	}
+	r.StatsFile = strings.TrimSpace(r.StatsFile)
+	if r.StatsFile == "" {
+		r.StatsFile = defaultReportStatsFile
+	}
+	for _, name := range r.Destinations {
+		d, ok := c.Destinations[name]
+		if !ok {
+			return fmt.Errorf("report: неизвестный получатель %q", name)
+		}
+		if IncidentType(d.Type) {
+			return fmt.Errorf("report: получатель %s типа %s не принимает отчёты", name, d.Type)
+		}
+	}
 	return nil
 }
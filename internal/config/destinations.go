@@ -0,0 +1,498 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultDestination имя получателя, который собирается из старых секций sender/telegram/format
+const DefaultDestination = "default"
+
+// DestinationConfig получатель алертов со своим форматом и своей очередью доставки
+type DestinationConfig struct {
+	Type       string                `yaml:"type"` // stdout | telegram | webhook | slack | mattermost | discord | smtp | pagerduty | opsgenie | issues | file | exec
+	Telegram   TelegramConfig        `yaml:"telegram"`
+	Webhook    WebhookConfig         `yaml:"webhook"`
+	Slack      IncomingWebhookConfig `yaml:"slack"`
+	Mattermost IncomingWebhookConfig `yaml:"mattermost"`
+	Discord    DiscordConfig         `yaml:"discord"`
+	SMTP       SMTPConfig            `yaml:"smtp"`
+	PagerDuty  PagerDutyConfig       `yaml:"pagerduty"`
+	Opsgenie   OpsgenieConfig        `yaml:"opsgenie"`
+	Issues     IssuesConfig          `yaml:"issues"`
+	File       FileConfig            `yaml:"file"`
+	Exec       ExecConfig            `yaml:"exec"`
+	Format     *FormatConfig         `yaml:"format"` // если не задан, используется общий format
+	Digest     *DigestConfig         `yaml:"digest"` // если задан, подходящие алерты копятся и уходят одной сводкой
+
+	// pagerduty, opsgenie, issues: ошибка, не повторявшаяся столько секунд, считается восстановленной,
+	// и получатель получает событие resolve; 0 = не закрывать
+	ResolveAfterSec int `yaml:"resolve_after_sec"`
+}
+
+// DigestConfig режим дайджеста: вместо отдельного сообщения на каждый алерт получатель
+// раз в interval_sec получает сводку, сгруппированную по правилу и fingerprint
+type DigestConfig struct {
+	IntervalSec int      `yaml:"interval_sec"` // по умолчанию 900
+	MaxBatch    int      `yaml:"max_batch"`    // сводка уходит раньше, если накопилось столько алертов; по умолчанию 100
+	Levels      []string `yaml:"levels"`       // какие уровни копить; пусто = любые, остальные уходят сразу
+	Rules       []string `yaml:"rules"`        // какие правила копить; пусто = любые
+}
+
+// IncomingWebhookConfig входящий вебхук Slack или Mattermost
+type IncomingWebhookConfig struct {
+	WebhookURL string `yaml:"webhook_url"`
+	Username   string `yaml:"username"`
+	Channel    string `yaml:"channel"` // Mattermost; Slack привязывает канал к самому вебхуку
+	IconEmoji  string `yaml:"icon_emoji"`
+	IconURL    string `yaml:"icon_url"`
+}
+
+// DiscordConfig вебхук канала Discord
+type DiscordConfig struct {
+	WebhookURL string `yaml:"webhook_url"`
+	Username   string `yaml:"username"`
+	AvatarURL  string `yaml:"avatar_url"`
+	ThreadID   string `yaml:"thread_id"` // публиковать в ветку форума
+}
+
+// SMTPConfig отправка писем через SMTP-сервер
+type SMTPConfig struct {
+	Host               string   `yaml:"host"`
+	Port               int      `yaml:"port"` // по умолчанию 587 для starttls, 465 для tls, 25 для none
+	TLS                string   `yaml:"tls"`  // starttls | tls (неявный TLS) | none; по умолчанию starttls
+	Auth               string   `yaml:"auth"` // plain | login | none; по умолчанию plain, если задан username
+	Username           string   `yaml:"username"`
+	Password           string   `yaml:"password"`
+	From               string   `yaml:"from"`
+	To                 []string `yaml:"to"`
+	SubjectTemplate    string   `yaml:"subject_template"` // text/template над LogEntry, например "[{{.Level}}] {{.Rule}}"
+	InsecureSkipVerify bool     `yaml:"insecure_skip_verify"`
+	TimeoutMS          int      `yaml:"timeout_ms"`
+}
+
+// PagerDutyConfig PagerDuty Events API v2
+type PagerDutyConfig struct {
+	RoutingKey string            `yaml:"routing_key"` // ключ интеграции сервиса
+	BaseURL    string            `yaml:"base_url"`    // по умолчанию https://events.pagerduty.com
+	Source     string            `yaml:"source"`      // по умолчанию имя хоста
+	Severities map[string]string `yaml:"severities"`  // уровень лога -> critical|error|warning|info
+	TimeoutMS  int               `yaml:"timeout_ms"`
+}
+
+// OpsgenieConfig Opsgenie Alert API
+type OpsgenieConfig struct {
+	APIKey     string            `yaml:"api_key"`
+	BaseURL    string            `yaml:"base_url"`   // по умолчанию https://api.opsgenie.com (для EU - https://api.eu.opsgenie.com)
+	Priorities map[string]string `yaml:"priorities"` // уровень лога -> P1..P5
+	Tags       []string          `yaml:"tags"`
+	Source     string            `yaml:"source"` // по умолчанию имя хоста
+	TimeoutMS  int               `yaml:"timeout_ms"`
+}
+
+// IssuesConfig задачи в трекере: новый fingerprint - новая задача, повтор - комментарий
+type IssuesConfig struct {
+	Provider         string   `yaml:"provider"` // github | gitlab | jira
+	BaseURL          string   `yaml:"base_url"` // по умолчанию https://api.github.com / https://gitlab.com; для jira обязателен
+	Token            string   `yaml:"token"`
+	Username         string   `yaml:"username"` // jira: email для Basic-авторизации; если пусто - Bearer token
+	Project          string   `yaml:"project"`  // github: owner/repo, gitlab: id или group/project, jira: ключ проекта
+	Labels           []string `yaml:"labels"`
+	IssueType        string   `yaml:"issue_type"`        // jira, по умолчанию Bug
+	Reopen           bool     `yaml:"reopen"`            // переоткрывать закрытую задачу при повторе
+	ReopenTransition string   `yaml:"reopen_transition"` // jira: имя перехода, по умолчанию Reopen
+	StateFile        string   `yaml:"state_file"`        // связь fingerprint -> задача; по умолчанию issues.<имя получателя>.json
+	SampleLines      int      `yaml:"sample_lines"`      // сколько исходных строк хранить как примеры, по умолчанию 5
+	TimeoutMS        int      `yaml:"timeout_ms"`
+
+	// не чаще одного комментария о повторах в задаче за столько секунд, по умолчанию 600; -1 = на каждый повтор
+	CommentIntervalSec int `yaml:"comment_interval_sec"`
+}
+
+// FileConfig архив отправленных алертов в JSONL с ротацией
+type FileConfig struct {
+	Path       string `yaml:"path"`         // по умолчанию alerts.<имя получателя>.jsonl
+	MaxSizeMB  int    `yaml:"max_size_mb"`  // ротация по размеру; 0 = без ограничения
+	Rotate     string `yaml:"rotate"`       // ротация по времени: hourly | daily | weekly; пусто = нет
+	MaxFiles   int    `yaml:"max_files"`    // сколько архивных файлов хранить; 0 = все
+	MaxAgeDays int    `yaml:"max_age_days"` // архивные файлы старше удаляются; 0 = без ограничения
+}
+
+// ExecConfig запуск локальной команды на каждый алерт
+type ExecConfig struct {
+	Command   []string          `yaml:"command"` // программа и аргументы, без shell
+	Dir       string            `yaml:"dir"`
+	Env       map[string]string `yaml:"env"`        // дополнительные переменные окружения
+	TimeoutMS int               `yaml:"timeout_ms"` // по умолчанию 30000; по таймауту убивается вся группа процессов
+}
+
+// WebhookConfig исходящий HTTP-запрос с JSON-телом из шаблона
+type WebhookConfig struct {
+	URL             string            `yaml:"url"`
+	Method          string            `yaml:"method"` // по умолчанию POST
+	Headers         map[string]string `yaml:"headers"`
+	BodyTemplate    string            `yaml:"body_template"`    // text/template над LogEntry, .Text и функцией json
+	HMACSecret      string            `yaml:"hmac_secret"`      // если задан, тело подписывается HMAC-SHA256
+	SignatureHeader string            `yaml:"signature_header"` // по умолчанию X-Signature-256
+	SuccessCodes    []int             `yaml:"success_codes"`    // по умолчанию любой 2xx
+	TimeoutMS       int               `yaml:"timeout_ms"`
+}
+
+// RouteConfig маршрут: алерты, подходящие под все заданные условия, уходят в destinations.
+// Применяются все подходящие маршруты, получатели объединяются.
+type RouteConfig struct {
+	Name         string            `yaml:"name"`
+	Levels       []string          `yaml:"levels"` // пусто = любые
+	Rules        []string          `yaml:"rules"`  // пусто = любые
+	Fields       map[string]string `yaml:"fields"` // поля из именованных групп, все должны совпасть
+	Destinations []string          `yaml:"destinations"`
+}
+
+// DestinationNames имена получателей в стабильном порядке
+func (c *Config) DestinationNames() []string {
+	names := make([]string, 0, len(c.Destinations))
+	for name := range c.Destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OutboxFile журнал outbox для получателя: у default - outbox.file как есть,
+// у остальных имя получателя добавляется перед расширением (outbox.oncall.jsonl)
+func (c *Config) OutboxFile(destination string) string {
+	if destination == DefaultDestination {
+		return c.Outbox.File
+	}
+	ext := filepath.Ext(c.Outbox.File)
+	return strings.TrimSuffix(c.Outbox.File, ext) + "." + destination + ext
+}
+
+func (c *Config) validateDestinations() error {
+	if len(c.Destinations) == 0 {
+		c.Sender.Type = strings.ToLower(strings.TrimSpace(c.Sender.Type))
+		switch c.Sender.Type {
+		case "stdout", "telegram":
+		default:
+			return fmt.Errorf("config: тип отправителя должен быть stdout|telegram")
+		}
+
+		if c.Sender.Type == "telegram" {
+			if err := c.Telegram.normalize(); err != nil {
+				return fmt.Errorf("config: %w", err)
+			}
+		}
+
+		c.Destinations = map[string]DestinationConfig{
+			DefaultDestination: {Type: c.Sender.Type, Telegram: c.Telegram},
+		}
+	}
+
+	for name, d := range c.Destinations {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, `/\\`) {
+			return fmt.Errorf("destinations: недопустимое имя получателя %q", name)
+		}
+		// имена с "_" зарезервированы для служебных получателей, например личных сообщений подписчикам
+		if strings.HasPrefix(name, "_") {
+			return fmt.Errorf("destinations: имя получателя %q не может начинаться с \"_\"", name)
+		}
+
+		d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+		switch d.Type {
+		case "stdout":
+		case "telegram":
+			if err := d.Telegram.normalize(); err != nil {
+				return fmt.Errorf("destinations.%s: %w", name, err)
+			}
+		case "webhook":
+			if strings.TrimSpace(d.Webhook.URL) == "" {
+				return fmt.Errorf("destinations.%s: не задан webhook.url", name)
+			}
+		case "slack":
+			if strings.TrimSpace(d.Slack.WebhookURL) == "" {
+				return fmt.Errorf("destinations.%s: не задан slack.webhook_url", name)
+			}
+		case "mattermost":
+			if strings.TrimSpace(d.Mattermost.WebhookURL) == "" {
+				return fmt.Errorf("destinations.%s: не задан mattermost.webhook_url", name)
+			}
+		case "discord":
+			if strings.TrimSpace(d.Discord.WebhookURL) == "" {
+				return fmt.Errorf("destinations.%s: не задан discord.webhook_url", name)
+			}
+		case "smtp":
+			if err := d.SMTP.normalize(); err != nil {
+				return fmt.Errorf("destinations.%s.smtp: %w", name, err)
+			}
+		case "pagerduty":
+			if strings.TrimSpace(d.PagerDuty.RoutingKey) == "" {
+				return fmt.Errorf("destinations.%s: не задан pagerduty.routing_key", name)
+			}
+			for level, sev := range d.PagerDuty.Severities {
+				switch sev {
+				case "critical", "error", "warning", "info":
+				default:
+					return fmt.Errorf("destinations.%s.pagerduty.severities.%s: должно быть critical|error|warning|info", name, level)
+				}
+			}
+		case "opsgenie":
+			if strings.TrimSpace(d.Opsgenie.APIKey) == "" {
+				return fmt.Errorf("destinations.%s: не задан opsgenie.api_key", name)
+			}
+			for level, p := range d.Opsgenie.Priorities {
+				switch p {
+				case "P1", "P2", "P3", "P4", "P5":
+				default:
+					return fmt.Errorf("destinations.%s.opsgenie.priorities.%s: должно быть P1..P5", name, level)
+				}
+			}
+		case "issues":
+			if err := d.Issues.normalize(name); err != nil {
+				return fmt.Errorf("destinations.%s.issues: %w", name, err)
+			}
+		case "file":
+			if err := d.File.normalize(name); err != nil {
+				return fmt.Errorf("destinations.%s.file: %w", name, err)
+			}
+		case "exec":
+			if len(d.Exec.Command) == 0 || strings.TrimSpace(d.Exec.Command[0]) == "" {
+				return fmt.Errorf("destinations.%s: не задан exec.command", name)
+			}
+			if d.Exec.TimeoutMS < 0 {
+				return fmt.Errorf("destinations.%s.exec: timeout_ms не может быть отрицательным", name)
+			}
+		default:
+			return fmt.Errorf("destinations.%s: тип должен быть stdout|telegram|webhook|slack|mattermost|discord|smtp|pagerduty|opsgenie|issues|file|exec", name)
+		}
+
+		if d.ResolveAfterSec != 0 {
+			switch {
+			case d.ResolveAfterSec < 0:
+				return fmt.Errorf("destinations.%s: resolve_after_sec не может быть отрицательным", name)
+			case d.Type != "pagerduty" && d.Type != "opsgenie" && d.Type != "issues":
+				return fmt.Errorf("destinations.%s: resolve_after_sec поддерживается только для pagerduty, opsgenie и issues", name)
+			}
+		}
+
+		if d.Digest != nil {
+			if err := d.Digest.normalize(d.Type); err != nil {
+				return fmt.Errorf("destinations.%s.digest: %w", name, err)
+			}
+		}
+
+		if d.Format == nil {
+			f := c.Format
+			d.Format = &f
+		} else if err := d.Format.compile(); err != nil {
+			return fmt.Errorf("destinations.%s.format.message_template: %w", name, err)
+		}
+
+		c.Destinations[name] = d
+	}
+
+	for i := range c.Routes {
+		r := &c.Routes[i]
+		if strings.TrimSpace(r.Name) == "" {
+			r.Name = fmt.Sprintf("route-%d", i+1)
+		}
+		for j := range r.Levels {
+			r.Levels[j] = strings.ToUpper(strings.TrimSpace(r.Levels[j]))
+		}
+		if len(r.Destinations) == 0 {
+			return fmt.Errorf("routes.%s: не указаны destinations", r.Name)
+		}
+		for _, d := range r.Destinations {
+			if _, ok := c.Destinations[d]; !ok {
+				return fmt.Errorf("routes.%s: неизвестный получатель %q", r.Name, d)
+			}
+		}
+	}
+
+	for _, d := range c.Unrouted {
+		if _, ok := c.Destinations[d]; !ok {
+			return fmt.Errorf("unrouted: неизвестный получатель %q", d)
+		}
+	}
+
+	return nil
+}
+
+// IncidentType получатель открывает инцидент или задачу по каждому алерту; сводки и отчёты он не принимает
+func IncidentType(destType string) bool {
+	switch destType {
+	case "pagerduty", "opsgenie", "issues":
+		return true
+	}
+	return false
+}
+
+// normalize проверяет настройки дайджеста и подставляет значения по умолчанию
+func (g *DigestConfig) normalize(destType string) error {
+	if IncidentType(destType) {
+		return fmt.Errorf("не поддерживается для %s: сводка не создаёт инцидентов", destType)
+	}
+	if g.IntervalSec < 0 || g.MaxBatch < 0 {
+		return fmt.Errorf("interval_sec и max_batch не могут быть отрицательными")
+	}
+	if g.IntervalSec == 0 {
+		g.IntervalSec = 900
+	}
+	if g.MaxBatch == 0 {
+		g.MaxBatch = 100
+	}
+	upper(g.Levels)
+	return nil
+}
+
+// normalize проверяет настройки SMTP и подставляет значения по умолчанию
+func (s *SMTPConfig) normalize() error {
+	s.Host = strings.TrimSpace(s.Host)
+	if s.Host == "" {
+		return fmt.Errorf("не задан host")
+	}
+	if strings.TrimSpace(s.From) == "" {
+		return fmt.Errorf("не задан from")
+	}
+	if len(s.To) == 0 {
+		return fmt.Errorf("не заданы получатели to")
+	}
+	for _, to := range s.To {
+		if strings.TrimSpace(to) == "" {
+			return fmt.Errorf("to не может содержать пустые значения")
+		}
+	}
+
+	s.TLS = strings.ToLower(strings.TrimSpace(s.TLS))
+	if s.TLS == "" {
+		s.TLS = "starttls"
+	}
+	switch s.TLS {
+	case "starttls":
+		if s.Port == 0 {
+			s.Port = 587
+		}
+	case "tls":
+		if s.Port == 0 {
+			s.Port = 465
+		}
+	case "none":
+		if s.Port == 0 {
+			s.Port = 25
+		}
+	default:
+		return fmt.Errorf("tls должен быть starttls|tls|none")
+	}
+
+	s.Auth = strings.ToLower(strings.TrimSpace(s.Auth))
+	if s.Auth == "" {
+		s.Auth = "none"
+		if s.Username != "" {
+			s.Auth = "plain"
+		}
+	}
+	switch s.Auth {
+	case "none":
+	case "plain", "login":
+		if s.Username == "" {
+			return fmt.Errorf("для auth %s нужен username", s.Auth)
+		}
+	default:
+		return fmt.Errorf("auth должен быть plain|login|none")
+	}
+	return nil
+}
+
+// normalize проверяет настройки трекера задач и подставляет значения по умолчанию
+func (i *IssuesConfig) normalize(destination string) error {
+	i.Provider = strings.ToLower(strings.TrimSpace(i.Provider))
+	switch i.Provider {
+	case "github", "gitlab":
+	case "jira":
+		if strings.TrimSpace(i.BaseURL) == "" {
+			return fmt.Errorf("для jira нужен base_url")
+		}
+	default:
+		return fmt.Errorf("provider должен быть github|gitlab|jira")
+	}
+	if strings.TrimSpace(i.Project) == "" {
+		return fmt.Errorf("не задан project")
+	}
+	if i.Token == "" {
+		return fmt.Errorf("не задан token")
+	}
+
+	if i.StateFile = strings.TrimSpace(i.StateFile); i.StateFile == "" {
+		i.StateFile = "issues." + destination + ".json"
+	}
+	if i.SampleLines <= 0 {
+		i.SampleLines = 5
+	}
+	switch {
+	case i.CommentIntervalSec < -1:
+		return fmt.Errorf("comment_interval_sec не может быть меньше -1")
+	case i.CommentIntervalSec == 0:
+		i.CommentIntervalSec = 600
+	}
+	if i.IssueType == "" {
+		i.IssueType = "Bug"
+	}
+	if i.ReopenTransition == "" {
+		i.ReopenTransition = "Reopen"
+	}
+	return nil
+}
+
+// normalize проверяет настройки архива и подставляет значения по умолчанию
+func (f *FileConfig) normalize(destination string) error {
+	if f.Path = strings.TrimSpace(f.Path); f.Path == "" {
+		f.Path = "alerts." + destination + ".jsonl"
+	}
+	if f.MaxSizeMB < 0 || f.MaxFiles < 0 || f.MaxAgeDays < 0 {
+		return fmt.Errorf("max_size_mb, max_files и max_age_days не могут быть отрицательными")
+	}
+	f.Rotate = strings.ToLower(strings.TrimSpace(f.Rotate))
+	switch f.Rotate {
+	case "", "hourly", "daily", "weekly":
+	default:
+		return fmt.Errorf("rotate должен быть hourly|daily|weekly")
+	}
+	return nil
+}
+
+// normalize проверяет настройки Telegram: нужен токен и хотя бы один чат
+func (t *TelegramConfig) normalize() error {
+	if t.BotToken == "" || (t.ChatID == "" && len(t.Chats) == 0) {
+		return fmt.Errorf("отсутствует токен телеграм бота и ChatId")
+	}
+	if t.DocumentThreshold == 0 {
+		t.DocumentThreshold = defaultTelegramDocumentThreshold
+	}
+	if t.SilentLevels == nil {
+		t.SilentLevels = []string{"DEBUG", "INFO"}
+	}
+	if t.LiveUpdateIntervalSec <= 0 {
+		t.LiveUpdateIntervalSec = defaultLiveUpdateIntervalSec
+	}
+	upper(t.SilentLevels)
+
+	for i := range t.Chats {
+		ch := &t.Chats[i]
+		if strings.TrimSpace(ch.ChatID) == "" {
+			return fmt.Errorf("telegram.chats[%d]: не задан chat_id", i)
+		}
+		upper(ch.Levels)
+		if ch.SilentLevels == nil {
+			ch.SilentLevels = t.SilentLevels
+		}
+		upper(ch.SilentLevels)
+	}
+	return nil
+}
+
+func upper(levels []string) {
+	for i := range levels {
+		levels[i] = strings.ToUpper(strings.TrimSpace(levels[i]))
+	}
+}
@@ -0,0 +1,88 @@
+package filter_from_config
+
+// ahoCorasick автомат для поиска сразу многих подстрок за один проход по строке.
+// Нужен для keywords_file: сотни ключевых слов не должны превращаться в сотни проверок на каждую строку.
+type ahoCorasick struct {
+	next   []map[byte]int // переходы бора
+	fail   []int          // суффиксные ссылки
+	output []int          // индекс слова, заканчивающегося в узле (или по суффиксной ссылке), -1 если нет
+	words  []string
+}
+
+func newAhoCorasick(words []string) *ahoCorasick {
+	ac := &ahoCorasick{
+		next:   []map[byte]int{{}},
+		fail:   []int{0},
+		output: []int{-1},
+		words:  words,
+	}
+
+	// Строим бор
+	for i, w := range words {
+		node := 0
+		for j := 0; j < len(w); j++ {
+			c := w[j]
+			child, ok := ac.next[node][c]
+			if !ok {
+				child = len(ac.next)
+				ac.next = append(ac.next, map[byte]int{})
+				ac.fail = append(ac.fail, 0)
+				ac.output = append(ac.output, -1)
+				ac.next[node][c] = child
+			}
+			node = child
+		}
+		if ac.output[node] == -1 {
+			ac.output[node] = i
+		}
+	}
+
+	// Суффиксные ссылки обходом в ширину
+	queue := make([]int, 0, len(ac.next))
+	for _, child := range ac.next[0] {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for c, child := range ac.next[node] {
+			f := ac.fail[node]
+			for f != 0 {
+				if _, ok := ac.next[f][c]; ok {
+					break
+				}
+				f = ac.fail[f]
+			}
+			if target, ok := ac.next[f][c]; ok && target != child {
+				ac.fail[child] = target
+			}
+			if ac.output[child] == -1 {
+				ac.output[child] = ac.output[ac.fail[child]]
+			}
+			queue = append(queue, child)
+		}
+	}
+
+	return ac
+}
+
+// find возвращает первое найденное слово
+func (ac *ahoCorasick) find(s string) (string, bool) {
+	node := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		for node != 0 {
+			if _, ok := ac.next[node][c]; ok {
+				break
+			}
+			node = ac.fail[node]
+		}
+		if child, ok := ac.next[node][c]; ok {
+			node = child
+		}
+		if ac.output[node] != -1 {
+			return ac.words[ac.output[node]], true
+		}
+	}
+	return "", false
+}
@@ -1,59 +1,275 @@
 package filter_from_config
 
 import (
+	"Bug_tracking_bot/internal/config"
 	"Bug_tracking_bot/internal/log_processing"
+	"bufio"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 )
 
 type Matcher struct {
 	allowedLevels map[string]struct{} // Если пустой, значит все 3 уровня
-	alertRegex    []*regexp.Regexp
+	rules         []rule
+}
+
+// rule правило из filters.rules или одиночный regex из filters.alert_regex (тогда его имя - сам regex)
+type rule struct {
+	name     string
+	levels   map[string]struct{} // дополнительный фильтр по уровню для конкретного правила
+	patterns []pattern
+}
+
+// pattern один шаблон правила; fields - извлечённые значения (только для regex/iregex и keywords_file)
+type pattern interface {
+	match(msg string) (fields map[string]string, ok bool)
 }
 
 func NewMatcher(levels []string, patterns []string) (*Matcher, error) {
-	m := &Matcher{
-		allowedLevels: make(map[string]struct{}),
-		alertRegex:    make([]*regexp.Regexp, 0, len(patterns)),
-	}
+	return New(config.FiltersConfig{Levels: levels, AlertRegex: patterns})
+}
 
-	// Нормализация уровней
-	for _, lvl := range levels {
-		lvl = strings.ToUpper(strings.TrimSpace(lvl))
-		if lvl != "" {
-			m.allowedLevels[lvl] = struct{}{}
-		}
+// New собирает Matcher из секции filters: сначала правила из alert_regex, затем из rules
+func New(cfg config.FiltersConfig) (*Matcher, error) {
+	m := &Matcher{
+		allowedLevels: levelSet(cfg.Levels),
+		rules:         make([]rule, 0, len(cfg.AlertRegex)+len(cfg.Rules)),
 	}
 
 	// Компиляция регулярных выражений
-	for _, p := range patterns {
+	for _, p := range cfg.AlertRegex {
 		re, err := regexp.Compile(p)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка компиляции регулярного выражения %q: %w", p, err)
 		}
-		m.alertRegex = append(m.alertRegex, re)
+		m.rules = append(m.rules, rule{name: p, patterns: []pattern{regexPattern{re}}})
+	}
+
+	for _, rc := range cfg.Rules {
+		r := rule{name: rc.Name, levels: levelSet(rc.Levels)}
+		for _, pc := range rc.Patterns {
+			p, err := compilePattern(pc)
+			if err != nil {
+				return nil, fmt.Errorf("правило %q: %w", rc.Name, err)
+			}
+			r.patterns = append(r.patterns, p)
+		}
+		m.rules = append(m.rules, r)
 	}
 
 	return m, nil
 }
 
 func (m *Matcher) Match(entry log_processing.LogEntry) bool {
+	_, ok := m.Find(entry)
+	return ok
+}
+
+// Find проверяет запись так же, как Match, и при совпадении возвращает её копию
+// с заполненными Rule и Fields (именованные группы первого совпавшего шаблона).
+func (m *Matcher) Find(entry log_processing.LogEntry) (log_processing.LogEntry, bool) {
 	// фильтр по уровню логов
-	if len(m.allowedLevels) > 0 {
-		if _, ok := m.allowedLevels[strings.ToUpper(entry.Level)]; !ok {
-			return false
+	if !m.LevelAllowed(entry.Level) {
+		return entry, false
+	}
+
+	for _, r := range m.rules {
+		if !r.levelAllowed(entry.Level) {
+			continue
+		}
+		fields, ok := r.match(entry.Message)
+		if !ok {
+			continue
+		}
+		entry.Rule = r.name
+		entry.Fields = fields
+		return entry, true
+	}
+	return entry, false
+}
+
+// LevelAllowed проходит ли уровень фильтр filters.levels
+func (m *Matcher) LevelAllowed(level string) bool {
+	if len(m.allowedLevels) == 0 {
+		return true
+	}
+	_, ok := m.allowedLevels[strings.ToUpper(level)]
+	return ok
+}
+
+// MatchingRules возвращает все правила, совпавшие с сообщением записи, а не только первое.
+// Используется для диагностики (команда check); общий фильтр по уровню здесь не учитывается.
+func (m *Matcher) MatchingRules(entry log_processing.LogEntry) []string {
+	var rules []string
+	for _, r := range m.rules {
+		if !r.levelAllowed(entry.Level) {
+			continue
+		}
+		if _, ok := r.match(entry.Message); ok {
+			rules = append(rules, r.name)
+		}
+	}
+	return rules
+}
+
+// RuleNames имена всех правил в порядке проверки
+func (m *Matcher) RuleNames() []string {
+	names := make([]string, 0, len(m.rules))
+	for _, r := range m.rules {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+func (r rule) levelAllowed(level string) bool {
+	if len(r.levels) == 0 {
+		return true
+	}
+	_, ok := r.levels[strings.ToUpper(level)]
+	return ok
+}
+
+// match правило срабатывает, если совпал любой из его шаблонов
+func (r rule) match(msg string) (map[string]string, bool) {
+	for _, p := range r.patterns {
+		if fields, ok := p.match(msg); ok {
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+func compilePattern(pc config.PatternConfig) (pattern, error) {
+	switch pc.Type {
+	case "", "regex", "iregex":
+		expr := pc.Value
+		if pc.Type == "iregex" || pc.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка компиляции регулярного выражения %q: %w", pc.Value, err)
+		}
+		return regexPattern{re}, nil
+	case "literal":
+		return newStringPattern(pc.Value, pc.IgnoreCase, strings.Contains), nil
+	case "prefix":
+		return newStringPattern(pc.Value, pc.IgnoreCase, strings.HasPrefix), nil
+	case "keywords_file":
+		words, err := readKeywords(pc.Value, pc.IgnoreCase)
+		if err != nil {
+			return nil, err
+		}
+		return keywordsPattern{ac: newAhoCorasick(words), ignoreCase: pc.IgnoreCase}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный тип шаблона %q", pc.Type)
+	}
+}
+
+type regexPattern struct {
+	re *regexp.Regexp
+}
+
+func (p regexPattern) match(msg string) (map[string]string, bool) {
+	sub := p.re.FindStringSubmatch(msg)
+	if sub == nil {
+		return nil, false
+	}
+	return namedGroups(p.re, sub), true
+}
+
+// stringPattern literal и prefix: без regex, спецсимволы вроде . и ( совпадают буквально
+type stringPattern struct {
+	value      string
+	ignoreCase bool
+	fn         func(s, substr string) bool
+}
+
+func newStringPattern(value string, ignoreCase bool, fn func(s, substr string) bool) stringPattern {
+	if ignoreCase {
+		value = strings.ToLower(value)
+	}
+	return stringPattern{value: value, ignoreCase: ignoreCase, fn: fn}
+}
+
+func (p stringPattern) match(msg string) (map[string]string, bool) {
+	if p.ignoreCase {
+		msg = strings.ToLower(msg)
+	}
+	return nil, p.fn(msg, p.value)
+}
+
+// keywordsPattern список ключевых слов из файла; найденное слово кладём в поле keyword
+type keywordsPattern struct {
+	ac         *ahoCorasick
+	ignoreCase bool
+}
+
+func (p keywordsPattern) match(msg string) (map[string]string, bool) {
+	if p.ignoreCase {
+		msg = strings.ToLower(msg)
+	}
+	word, ok := p.ac.find(msg)
+	if !ok {
+		return nil, false
+	}
+	return map[string]string{"keyword": word}, true
+}
+
+// readKeywords читает файл: одно ключевое слово на строку, пустые строки и строки с # пропускаются
+func readKeywords(path string, ignoreCase bool) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла ключевых слов: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		w := strings.TrimSpace(sc.Text())
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
 		}
+		if ignoreCase {
+			w = strings.ToLower(w)
+		}
+		words = append(words, w)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла ключевых слов: %w", err)
 	}
-	// проверка регулярного выражения по сообщению
-	if len(m.alertRegex) == 0 {
-		return false
+	if len(words) == 0 {
+		return nil, fmt.Errorf("файл ключевых слов %s пустой", path)
 	}
+	return words, nil
+}
+
+func levelSet(levels []string) map[string]struct{} {
+	set := make(map[string]struct{})
+	// Нормализация уровней
+	for _, lvl := range levels {
+		lvl = strings.ToUpper(strings.TrimSpace(lvl))
+		if lvl != "" {
+			set[lvl] = struct{}{}
+		}
+	}
+	return set
+}
 
-	for _, re := range m.alertRegex {
-		if re.MatchString(entry.Message) {
-			return true
+// namedGroups собирает значения именованных групп; безымянные группы пропускаются
+func namedGroups(re *regexp.Regexp, sub []string) map[string]string {
+	var fields map[string]string
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string)
 		}
+		fields[name] = sub[i]
 	}
-	return false
+	return fields
 }
@@ -99,3 +99,28 @@ func TestMatcher_InvalidRegex(t *testing.T) {
 		t.Fatal("ожидается ошибка для неверного регулярного выражения , получено nil")
 	}
 }
+
+func TestMatcher_Find_NamedGroups(t *testing.T) {
+	m, err := NewMatcher(nil, []string{`^order (?P<order_id>\d+) failed: (\w+)$`})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	entry := log_processing.LogEntry{
+		Timestamp: time.Now(),
+		Level:     "ERROR",
+		Message:   "order 123 failed: timeout",
+		Raw:       "2026-02-25T17:24:25+03:00 [ERROR] order 123 failed: timeout",
+	}
+
+	got, ok := m.Find(entry)
+	if !ok {
+		t.Fatal("ожидается совпадение")
+	}
+	if got.Rule != `^order (?P<order_id>\d+) failed: (\w+)$` {
+		t.Fatalf("неверное правило: %q", got.Rule)
+	}
+	if len(got.Fields) != 1 || got.Fields["order_id"] != "123" {
+		t.Fatalf("ожидается order_id=123 и только именованные группы, получено %v", got.Fields)
+	}
+}
@@ -0,0 +1,82 @@
+package filter_from_config
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher_PatternTypes(t *testing.T) {
+	m, err := New(config.FiltersConfig{Rules: []config.RuleConfig{
+		{Name: "literal", Patterns: []config.PatternConfig{{Type: "literal", Value: "failed (code 5)."}}},
+		{Name: "prefix", Patterns: []config.PatternConfig{{Type: "prefix", Value: "invalid", IgnoreCase: true}}},
+		{Name: "iregex", Patterns: []config.PatternConfig{{Type: "iregex", Value: `^timeout after \d+s$`}}},
+	}})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	cases := map[string]string{
+		"payment failed (code 5).": "literal",
+		"Invalid input received":   "prefix",
+		"TIMEOUT after 30s":        "iregex",
+		"payment failed (code 55)": "",
+	}
+	for msg, want := range cases {
+		got, ok := m.Find(log_processing.LogEntry{Level: "ERROR", Message: msg})
+		if want == "" {
+			if ok {
+				t.Fatalf("%q: ожидается отсутствие совпадения, сработало правило %q", msg, got.Rule)
+			}
+			continue
+		}
+		if !ok || got.Rule != want {
+			t.Fatalf("%q: ожидается правило %q, получено %q (ok=%v)", msg, want, got.Rule, ok)
+		}
+	}
+}
+
+func TestMatcher_KeywordsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keywords.txt")
+	content := "# известные ошибки\nout of memory\nconnection reset\nsegfault\n\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("ошибка записи файла: %v", err)
+	}
+
+	m, err := New(config.FiltersConfig{Rules: []config.RuleConfig{
+		{Name: "known-bad", Levels: []string{"ERROR"}, Patterns: []config.PatternConfig{{Type: "keywords_file", Value: path, IgnoreCase: true}}},
+	}})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	got, ok := m.Find(log_processing.LogEntry{Level: "ERROR", Message: "worker 3: Connection Reset by peer"})
+	if !ok || got.Fields["keyword"] != "connection reset" {
+		t.Fatalf("ожидается совпадение по ключевому слову, получено %v (ok=%v)", got.Fields, ok)
+	}
+
+	if m.Match(log_processing.LogEntry{Level: "INFO", Message: "segfault"}) {
+		t.Fatal("уровень правила должен отфильтровать INFO")
+	}
+	if m.Match(log_processing.LogEntry{Level: "ERROR", Message: "connection ok"}) {
+		t.Fatal("ожидается отсутствие совпадения")
+	}
+}
+
+func TestAhoCorasick_OverlappingWords(t *testing.T) {
+	ac := newAhoCorasick([]string{"he", "she", "hers", "his"})
+
+	for _, s := range []string{"ushers", "ahishe", "xhe"} {
+		if _, ok := ac.find(s); !ok {
+			t.Fatalf("ожидается совпадение в %q", s)
+		}
+	}
+	if w, ok := ac.find("hxsxr"); ok {
+		t.Fatalf("ожидается отсутствие совпадения, найдено %q", w)
+	}
+	if w, _ := ac.find("xxshe"); w != "she" {
+		t.Fatalf("ожидается she, получено %q", w)
+	}
+}
@@ -0,0 +1,24 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+)
+
+// Format форматирует запись для получателя типа destType (см. config.DestinationConfig.Type)
+func Format(destType string, entry log_processing.LogEntry, cfg config.FormatConfig) string {
+	switch destType {
+	case "telegram":
+		return FormatTelegram(entry, cfg)
+	case "slack":
+		return FormatSlack(entry, cfg)
+	case "mattermost":
+		return FormatMattermost(entry, cfg)
+	case "discord":
+		return FormatDiscord(entry, cfg)
+	case "smtp":
+		return FormatEmail(entry, cfg)
+	default:
+		return FormatStdout(entry, cfg)
+	}
+}
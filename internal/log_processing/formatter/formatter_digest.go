@@ -0,0 +1,70 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type digestGroup struct {
+	rule, key, level, message, raw string
+	count                          int
+	first, last                    time.Time
+}
+
+// FormatDigest одна сводка по набору алертов: группировка по StableKey (правило и нормализованное сообщение
+// или group_by), количество и время первого/последнего появления. Разметка выбирается по типу получателя destType,
+// текст группы, ключ и исходная строка - по format получателя.
+func FormatDigest(title string, entries []log_processing.LogEntry, destType string, cfg config.FormatConfig) string {
+	m := markupFor(destType)
+	esc, bold, code := m.esc, m.bold, m.code
+
+	groups := make(map[string]*digestGroup)
+	var order []*digestGroup
+	for _, e := range entries {
+		key := protect_from_duplicates.StableKey(e)
+		g, ok := groups[key]
+		if !ok {
+			// без шаблона показываем нормализованное сообщение: "order <n> failed" описывает всю группу
+			message := protect_from_duplicates.Normalize(e.Message)
+			if cfg.MessageTemplate != "" {
+				message = renderMessage(e, cfg)
+			}
+			g = &digestGroup{rule: e.Rule, key: key, level: e.Level, message: message, raw: e.Raw, first: e.Timestamp}
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.count++
+		if e.Timestamp.Before(g.first) {
+			g.first = e.Timestamp
+		}
+		if e.Timestamp.After(g.last) {
+			g.last = e.Timestamp
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return order[i].count > order[j].count })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📋 %s\n", bold(esc(title)))
+	fmt.Fprintf(&b, "%s %d, %s %d\n\n", bold("Алертов:"), len(entries), bold("групп:"), len(order))
+
+	for _, g := range order {
+		fmt.Fprintf(&b, "%s ×%d %s\n", esc(g.level), g.count, esc(g.message))
+		if g.rule != "" {
+			fmt.Fprintf(&b, "  правило: %s\n", code(esc(g.rule)))
+		}
+		if cfg.IncludeFingerprint {
+			fmt.Fprintf(&b, "  ключ: %s\n", code(esc(g.key)))
+		}
+		fmt.Fprintf(&b, "  первый: %s, последний: %s\n", g.first.Format("2006-01-02 15:04:05"), g.last.Format("2006-01-02 15:04:05"))
+		if cfg.IncludeRaw {
+			fmt.Fprintf(&b, "  пример: %s\n", code(esc(g.raw)))
+		}
+	}
+
+	return notice(destType, b.String())
+}
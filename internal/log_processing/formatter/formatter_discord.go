@@ -0,0 +1,134 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Ограничения Discord на embed (в символах)
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordFieldValueLimit  = 1024
+	discordFieldsLimit      = 25
+	discordFooterLimit      = 2048
+	discordEmbedTotalLimit  = 6000
+	discordContentLimit     = 2000
+)
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+}
+
+type discordPayload struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []discordEmbed `json:"embeds,omitempty"`
+}
+
+// FormatDiscord payload вебхука Discord: embed с цветом уровня, полями и исходной строкой в блоке кода
+func FormatDiscord(entry log_processing.LogEntry, cfg config.FormatConfig) string {
+	msg := EscapeDiscord(renderMessage(entry, cfg))
+
+	embed := discordEmbed{
+		Title:     truncateRunes(entry.Level+": "+msg, discordTitleLimit),
+		Color:     discordColor(entry.Level),
+		Timestamp: entry.Timestamp.Format(time.RFC3339),
+	}
+
+	fields := []discordField{
+		{Name: "Время", Value: entry.Timestamp.Format("2006-01-02 15:04:05"), Inline: true},
+		{Name: "Уровень", Value: EscapeDiscord(entry.Level), Inline: true},
+	}
+	if cfg.IncludeFingerprint {
+		fields = append(fields, discordField{Name: "Уникальный ключ", Value: "`" + fingerprint(entry) + "`", Inline: true})
+	}
+	if entry.Source != "" {
+		fields = append(fields, discordField{Name: "Источник", Value: truncateRunes(EscapeDiscord(entry.Source), discordFieldValueLimit), Inline: true})
+	}
+	if entry.Rule != "" {
+		fields = append(fields, discordField{Name: "Правило", Value: truncateRunes(EscapeDiscord(entry.Rule), discordFieldValueLimit)})
+	}
+	for _, name := range sortedFieldNames(entry.Fields) {
+		v := entry.Fields[name]
+		if v == "" {
+			v = "-" // пустое значение поля Discord не принимает
+		}
+		fields = append(fields, discordField{
+			Name:   truncateRunes(EscapeDiscord(name), discordTitleLimit),
+			Value:  truncateRunes(EscapeDiscord(v), discordFieldValueLimit),
+			Inline: true,
+		})
+	}
+	if len(fields) > discordFieldsLimit {
+		fields = fields[:discordFieldsLimit]
+	}
+	embed.Fields = fields
+
+	if cfg.IncludeRaw {
+		// запас под ограничение на суммарный размер embed
+		budget := discordEmbedTotalLimit - embedSize(embed) - len("```\n\n```") - 1
+		budget = min(budget, discordDescriptionLimit-len("```\n\n```"))
+		raw := strings.ReplaceAll(entry.Raw, "```", "'''")
+		embed.Description = "```\n" + truncateRunes(raw, max(budget, 0)) + "\n```"
+	}
+
+	return marshalJSON(discordPayload{Embeds: []discordEmbed{embed}})
+}
+
+// embedSize сколько символов Discord насчитает в embed (title, description, поля, footer)
+func embedSize(e discordEmbed) int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	for _, f := range e.Fields {
+		n += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+	}
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
+	}
+	return n
+}
+
+func discordColor(level string) int {
+	c, _ := strconv.ParseInt(strings.TrimPrefix(levelColor(level), "#"), 16, 32)
+	return int(c)
+}
+
+// discordReplacer символы Markdown Discord экранируются обратным слэшем
+var discordReplacer = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, "@", "@\u200b",
+)
+
+// EscapeDiscord экранирует текст для Markdown Discord; @ разрывается, чтобы не было упоминаний @everyone
+func EscapeDiscord(s string) string {
+	return discordReplacer.Replace(s)
+}
+
+// truncateRunes обрезает строку до limit символов
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	if limit <= 1 {
+		return ""
+	}
+	r := []rune(s)
+	return string(r[:limit-1]) + "…"
+}
@@ -0,0 +1,55 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormatDiscord_EmbedFieldsAndLimits(t *testing.T) {
+	e := testEntry()
+	e.Source = "/var/log/app.log"
+	e.Raw = strings.Repeat("x", 10_000)
+	out := FormatDiscord(e, config.FormatConfig{IncludeRaw: true, IncludeFingerprint: true})
+
+	var p discordPayload
+	if err := json.Unmarshal([]byte(out), &p); err != nil {
+		t.Fatalf("payload должен быть валидным JSON: %v", err)
+	}
+	if len(p.Embeds) != 1 {
+		t.Fatalf("ожидается один embed, получено %d", len(p.Embeds))
+	}
+	embed := p.Embeds[0]
+	if embed.Color != 0xd32f2f {
+		t.Fatalf("ожидается цвет ERROR, получено %#x", embed.Color)
+	}
+	if !strings.Contains(embed.Title, `payment <failed> & \*retried\*`) {
+		t.Fatalf("ожидается экранирование Markdown в заголовке, получено %q", embed.Title)
+	}
+
+	names := make([]string, 0, len(embed.Fields))
+	for _, f := range embed.Fields {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "Время,Уровень,Уникальный ключ,Источник,Правило" {
+		t.Fatalf("неверный набор полей: %s", got)
+	}
+
+	if !strings.HasPrefix(embed.Description, "```\n") || !strings.HasSuffix(embed.Description, "\n```") {
+		t.Fatalf("исходная строка должна быть в блоке кода")
+	}
+	if n := utf8.RuneCountInString(embed.Description); n > discordDescriptionLimit {
+		t.Fatalf("description превышает лимит: %d", n)
+	}
+	if n := embedSize(embed); n > discordEmbedTotalLimit {
+		t.Fatalf("embed превышает общий лимит: %d", n)
+	}
+}
+
+func TestEscapeDiscord_BreaksMentions(t *testing.T) {
+	if got := EscapeDiscord("@everyone"); got == "@everyone" {
+		t.Fatal("упоминание @everyone должно быть разорвано")
+	}
+}
@@ -0,0 +1,61 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// emailPayload текстовая и HTML-версии письма; SMTPSender собирает из них multipart/alternative
+type emailPayload struct {
+	Text string `json:"text"`
+	HTML string `json:"html"`
+}
+
+// FormatEmail письмо: текст как у stdout и HTML-версия из FormatEmailHTML
+func FormatEmail(entry log_processing.LogEntry, cfg config.FormatConfig) string {
+	return marshalJSON(emailPayload{
+		Text: FormatStdout(entry, cfg),
+		HTML: FormatEmailHTML(entry, cfg),
+	})
+}
+
+// FormatEmailHTML HTML-версия письма: таблица с полями, цвет уровня и исходная строка в <pre>
+func FormatEmailHTML(entry log_processing.LogEntry, cfg config.FormatConfig) string {
+	var b strings.Builder
+
+	level := html.EscapeString(entry.Level)
+	msg := html.EscapeString(renderMessage(entry, cfg))
+
+	b.WriteString(`<!DOCTYPE html><html><body style="font-family:sans-serif">`)
+	fmt.Fprintf(&b, `<h2 style="border-left:6px solid %s;padding-left:8px">%s: %s</h2>`, levelColor(entry.Level), level, msg)
+
+	b.WriteString(`<table cellpadding="4" style="border-collapse:collapse">`)
+	row := func(name, value string) {
+		fmt.Fprintf(&b, `<tr><th align="left">%s</th><td>%s</td></tr>`, html.EscapeString(name), value)
+	}
+	row("Уровень", level)
+	row("Время", entry.Timestamp.Format("2006-01-02 15:04:05"))
+	if entry.Rule != "" {
+		row("Правило", html.EscapeString(entry.Rule))
+	}
+	if entry.Source != "" {
+		row("Источник", html.EscapeString(entry.Source))
+	}
+	for _, name := range sortedFieldNames(entry.Fields) {
+		row(name, "<code>"+html.EscapeString(entry.Fields[name])+"</code>")
+	}
+	if cfg.IncludeFingerprint {
+		row("Уникальный ключ", "<code>"+html.EscapeString(fingerprint(entry))+"</code>")
+	}
+	b.WriteString(`</table>`)
+
+	if cfg.IncludeRaw {
+		fmt.Fprintf(&b, `<h3>Исходный лог</h3><pre style="background:#f5f5f5;padding:8px;white-space:pre-wrap">%s</pre>`, html.EscapeString(entry.Raw))
+	}
+
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
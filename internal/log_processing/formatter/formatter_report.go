@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/report"
+	"fmt"
+	"strings"
+)
+
+// FormatReport регулярный отчёт: строки по источникам, уровни, топ ошибок и правил,
+// новые алерты и ошибки доставки. Разметка выбирается по типу получателя destType.
+func FormatReport(r report.Report, destType string) string {
+	m := markupFor(destType)
+	esc, bold, code := m.esc, m.bold, m.code
+	const ts = "2006-01-02 15:04"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 %s %s - %s\n", bold("Отчёт за"), r.From.Format(ts), r.To.Format(ts))
+
+	fmt.Fprintf(&b, "\n%s\n", bold("Прочитано строк:"))
+	if len(r.Sources) == 0 {
+		b.WriteString("  нет\n")
+	}
+	for _, s := range r.Sources {
+		fmt.Fprintf(&b, "  %s: %d, не разобрано: %d\n", code(esc(s.Source)), s.Lines, s.ParseFailures)
+	}
+
+	levels := make([]string, len(r.Levels))
+	for i, l := range r.Levels {
+		levels[i] = fmt.Sprintf("%s %d", esc(l.Name), l.Count)
+	}
+	if len(levels) == 0 {
+		levels = []string{"нет"}
+	}
+	fmt.Fprintf(&b, "\n%s %s\n", bold("По уровням:"), strings.Join(levels, ", "))
+
+	fmt.Fprintf(&b, "\n%s\n", bold("Частые ошибки:"))
+	if len(r.TopErrors) == 0 {
+		b.WriteString("  нет\n")
+	}
+	for _, e := range r.TopErrors {
+		fmt.Fprintf(&b, "  ×%d %s\n", e.Count, esc(e.Name))
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", bold("Сработавшие правила:"))
+	if len(r.TopRules) == 0 {
+		b.WriteString("  нет\n")
+	}
+	for _, rule := range r.TopRules {
+		fmt.Fprintf(&b, "  %s ×%d\n", code(esc(rule.Name)), rule.Count)
+	}
+
+	fmt.Fprintf(&b, "\n%s %d\n", bold("Новые алерты:"), r.NewTotal)
+	for _, fp := range r.NewFingerprints {
+		line := esc(fp.Message)
+		if fp.Rule != "" {
+			line = code(esc(fp.Rule)) + " " + line
+		}
+		fmt.Fprintf(&b, "  %s, впервые %s\n", line, fp.FirstSeen.Format(ts))
+	}
+	if more := r.NewTotal - len(r.NewFingerprints); more > 0 {
+		fmt.Fprintf(&b, "  …и ещё %d\n", more)
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", bold("Ошибки доставки:"))
+	if len(r.Delivery) == 0 {
+		b.WriteString("  нет\n")
+	}
+	for _, d := range r.Delivery {
+		fmt.Fprintf(&b, "  %s: неудачных попыток %d, потеряно сообщений %d\n", esc(d.Destination), d.Failed, d.Dropped)
+	}
+
+	return notice(destType, b.String())
+}
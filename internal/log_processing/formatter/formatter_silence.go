@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/silence"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormatSilenceSummary сводка по закончившемуся silence: что и сколько было подавлено.
+// Разметка выбирается по типу получателя destType.
+func FormatSilenceSummary(s silence.Silence, destType string) string {
+	m := markupFor(destType)
+	esc, bold := m.esc, m.bold
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔕 %s %s\n\n", bold("Silence закончился:"), esc(s.ID))
+	fmt.Fprintf(&b, "%s %s\n", bold("Условие:"), esc(describeSilence(s)))
+	fmt.Fprintf(&b, "%s %s - %s\n", bold("Период:"),
+		s.StartsAt.Format("2006-01-02 15:04:05"), s.EndsAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "%s %s\n", bold("Автор:"), esc(s.CreatedBy))
+	if s.Comment != "" {
+		fmt.Fprintf(&b, "%s %s\n", bold("Комментарий:"), esc(s.Comment))
+	}
+	fmt.Fprintf(&b, "\n%s %d\n", bold("Подавлено алертов:"), s.Suppressed)
+
+	rules := make([]string, 0, len(s.ByRule))
+	for r := range s.ByRule {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return s.ByRule[rules[i]] > s.ByRule[rules[j]] })
+	for _, r := range rules {
+		fmt.Fprintf(&b, "  %s: %d\n", esc(r), s.ByRule[r])
+	}
+
+	return notice(destType, b.String())
+}
+
+func describeSilence(s silence.Silence) string {
+	var parts []string
+	if s.Rule != "" {
+		parts = append(parts, "rule="+s.Rule)
+	}
+	if s.Level != "" {
+		parts = append(parts, "level="+s.Level)
+	}
+	if s.Fingerprint != "" {
+		parts = append(parts, "fingerprint="+s.Fingerprint)
+	}
+	for _, k := range sortedFieldNames(s.Fields) {
+		parts = append(parts, k+"="+s.Fields[k])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,149 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// slackTextLimit ограничение Slack на текст одного блока section
+const slackTextLimit = 3000
+
+// levelColor цвет полосы вложения по уровню
+func levelColor(level string) string {
+	switch level {
+	case "FATAL", "ERROR":
+		return "#d32f2f"
+	case "WARN":
+		return "#ef6c00"
+	case "INFO":
+		return "#2e7d32"
+	case "DEBUG":
+		return "#f9a825"
+	default:
+		return "#757575"
+	}
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackPayload struct {
+	Text        string            `json:"text"` // для уведомлений и клиентов без Block Kit
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// FormatSlack payload входящего вебхука Slack: Block Kit внутри вложения с цветом уровня
+func FormatSlack(entry log_processing.LogEntry, cfg config.FormatConfig) string {
+	msg := EscapeMrkdwn(renderMessage(entry, cfg))
+
+	fields := []slackText{
+		{Type: "mrkdwn", Text: "*Уровень:*\n" + EscapeMrkdwn(entry.Level)},
+		{Type: "mrkdwn", Text: "*Время:*\n" + entry.Timestamp.Format("2006-01-02 15:04:05")},
+	}
+	if entry.Rule != "" {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*Правило:*\n" + EscapeMrkdwn(entry.Rule)})
+	}
+	for _, name := range sortedFieldNames(entry.Fields) {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s:*\n%s", EscapeMrkdwn(name), EscapeMrkdwn(entry.Fields[name]))})
+	}
+	if len(fields) > 10 { // Slack принимает не больше 10 полей в section
+		fields = fields[:10]
+	}
+
+	blocks := []slackBlock{
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate("*Сообщение:* "+msg, slackTextLimit)}},
+		{Type: "section", Fields: fields},
+	}
+	if cfg.IncludeRaw {
+		raw := "```" + strings.ReplaceAll(EscapeMrkdwn(entry.Raw), "```", "'''") + "```"
+		blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate(raw, slackTextLimit)}})
+	}
+	if cfg.IncludeFingerprint {
+		blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: "Уникальный ключ: `" + fingerprint(entry) + "`"}}})
+	}
+
+	return marshalJSON(slackPayload{
+		Text:        truncate(fmt.Sprintf("%s %s", entry.Level, msg), slackTextLimit),
+		Attachments: []slackAttachment{{Color: levelColor(entry.Level), Blocks: blocks}},
+	})
+}
+
+type mattermostField struct {
+	Short bool   `json:"short"`
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type mattermostAttachment struct {
+	Fallback string            `json:"fallback"`
+	Color    string            `json:"color"`
+	Title    string            `json:"title"`
+	Text     string            `json:"text"`
+	Fields   []mattermostField `json:"fields,omitempty"`
+	Footer   string            `json:"footer,omitempty"`
+}
+
+type mattermostPayload struct {
+	Attachments []mattermostAttachment `json:"attachments"`
+}
+
+// FormatMattermost payload входящего вебхука Mattermost: Slack-совместимое вложение с Markdown
+func FormatMattermost(entry log_processing.LogEntry, cfg config.FormatConfig) string {
+	msg := renderMessage(entry, cfg)
+
+	fields := []mattermostField{
+		{Short: true, Title: "Уровень", Value: EscapeMarkdown(entry.Level)},
+		{Short: true, Title: "Время", Value: entry.Timestamp.Format("2006-01-02 15:04:05")},
+	}
+	if entry.Rule != "" {
+		fields = append(fields, mattermostField{Short: true, Title: "Правило", Value: EscapeMarkdown(entry.Rule)})
+	}
+	for _, name := range sortedFieldNames(entry.Fields) {
+		fields = append(fields, mattermostField{Short: true, Title: name, Value: EscapeMarkdown(entry.Fields[name])})
+	}
+
+	att := mattermostAttachment{
+		Fallback: fmt.Sprintf("%s %s", entry.Level, msg),
+		Color:    levelColor(entry.Level),
+		Title:    entry.Level,
+		Text:     EscapeMarkdown(msg),
+		Fields:   fields,
+	}
+	if cfg.IncludeRaw {
+		att.Text += "\n```\n" + strings.ReplaceAll(entry.Raw, "```", "'''") + "\n```"
+	}
+	if cfg.IncludeFingerprint {
+		att.Footer = "Уникальный ключ: " + fingerprint(entry)
+	}
+
+	return marshalJSON(mattermostPayload{Attachments: []mattermostAttachment{att}})
+}
+
+// truncate обрезает строку до limit байт, не разрывая UTF-8 символы
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit - len("…")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
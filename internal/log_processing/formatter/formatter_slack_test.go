@@ -0,0 +1,70 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntry() log_processing.LogEntry {
+	ts, _ := time.Parse(time.RFC3339, "2026-02-25T17:24:25+03:00")
+	return log_processing.LogEntry{
+		Timestamp: ts,
+		Level:     "ERROR",
+		Message:   "payment <failed> & *retried*",
+		Raw:       "2026-02-25T17:24:25+03:00 [ERROR] payment <failed> & *retried*",
+		Rule:      "payments",
+	}
+}
+
+func TestFormatSlack_EscapesAndRespectsOptions(t *testing.T) {
+	out := FormatSlack(testEntry(), config.FormatConfig{IncludeRaw: false, IncludeFingerprint: true})
+
+	var p slackPayload
+	if err := json.Unmarshal([]byte(out), &p); err != nil {
+		t.Fatalf("payload должен быть валидным JSON: %v", err)
+	}
+	if len(p.Attachments) != 1 || p.Attachments[0].Color != levelColor("ERROR") {
+		t.Fatalf("ожидается одно вложение с цветом ERROR, получено %+v", p.Attachments)
+	}
+	if !strings.Contains(out, "payment &lt;failed&gt; &amp; *retried*") {
+		t.Fatalf("ожидается экранирование & < > для mrkdwn:\n%s", out)
+	}
+	if strings.Contains(out, "```") {
+		t.Fatal("include_raw=false: исходная строка не должна попадать в сообщение")
+	}
+	if !strings.Contains(out, "Уникальный ключ") {
+		t.Fatal("include_fingerprint=true: ожидается fingerprint")
+	}
+}
+
+func TestFormatMattermost_EscapesMarkdown(t *testing.T) {
+	out := FormatMattermost(testEntry(), config.FormatConfig{IncludeRaw: true})
+
+	var p mattermostPayload
+	if err := json.Unmarshal([]byte(out), &p); err != nil {
+		t.Fatalf("payload должен быть валидным JSON: %v", err)
+	}
+	text := p.Attachments[0].Text
+	if !strings.HasPrefix(text, `payment &lt;failed&gt; & \*retried\*`) {
+		t.Fatalf("ожидается экранирование Markdown, получено %q", text)
+	}
+	if !strings.Contains(text, "```\n"+testEntry().Raw+"\n```") {
+		t.Fatalf("include_raw=true: ожидается исходная строка в блоке кода, получено %q", text)
+	}
+	if p.Attachments[0].Footer != "" {
+		t.Fatal("include_fingerprint=false: fingerprint не ожидается")
+	}
+}
+
+func TestNotice_WrapsForJSONDestinations(t *testing.T) {
+	out := FormatDigest("Сводка", []log_processing.LogEntry{testEntry()}, "slack", config.FormatConfig{})
+
+	var p map[string]string
+	if err := json.Unmarshal([]byte(out), &p); err != nil || !strings.Contains(p["text"], "*Сводка*") {
+		t.Fatalf("сводка для slack должна быть JSON с mrkdwn-текстом, получено %q (%v)", out, err)
+	}
+}
@@ -3,7 +3,6 @@ package formatter
 import (
 	"Bug_tracking_bot/internal/config"
 	"Bug_tracking_bot/internal/log_processing"
-	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
 	"fmt"
 )
 
@@ -12,8 +11,8 @@ func FormatStdout(entry log_processing.LogEntry, cfg config.FormatConfig) string
 
 	time := entry.Timestamp.Format("2006-01-02 15:04:05")
 	level := entry.Level
-	msg := entry.Message
-	fp := protect_from_duplicates.Fingerprint(entry.Raw)
+	msg := renderMessage(entry, cfg)
+	fp := fingerprint(entry)
 	raw := entry.Raw
 
 	text += fmt.Sprintf(
@@ -23,6 +22,10 @@ func FormatStdout(entry log_processing.LogEntry, cfg config.FormatConfig) string
 		level, time, msg,
 	)
 
+	for _, name := range sortedFieldNames(entry.Fields) {
+		text += fmt.Sprintf("%s: %s\n", name, entry.Fields[name])
+	}
+
 	if cfg.IncludeFingerprint {
 		text += fmt.Sprintf("Уникальный ключ: %s\n", fp)
 	}
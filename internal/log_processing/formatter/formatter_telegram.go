@@ -3,7 +3,6 @@ package formatter
 import (
 	"Bug_tracking_bot/internal/config"
 	"Bug_tracking_bot/internal/log_processing"
-	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
 	"fmt"
 	"html"
 )
@@ -12,18 +11,11 @@ func FormatTelegram(entry log_processing.LogEntry, cfg config.FormatConfig) stri
 	var text string
 	time := entry.Timestamp.Format("2006-01-02 15:04:05")
 	level := html.EscapeString(entry.Level)
-	msg := html.EscapeString(entry.Message)
-	fp := html.EscapeString(protect_from_duplicates.Fingerprint(entry.Raw))
+	msg := html.EscapeString(renderMessage(entry, cfg))
+	fp := html.EscapeString(fingerprint(entry))
 	raw := html.EscapeString(entry.Raw)
 
-	switch level {
-	case "INFO":
-		text += "🟢"
-	case "DEBUG":
-		text += "🟡"
-	case "ERROR":
-		text += "🔴"
-	}
+	text += LevelEmoji(entry.Level)
 
 	text += fmt.Sprintf(
 		"<b> Уровень </b>%s\n\n"+
@@ -32,6 +24,13 @@ func FormatTelegram(entry log_processing.LogEntry, cfg config.FormatConfig) stri
 		level, time, msg,
 	)
 
+	for _, name := range sortedFieldNames(entry.Fields) {
+		text += fmt.Sprintf("<b>%s:</b> <code>%s</code>\n", html.EscapeString(name), html.EscapeString(entry.Fields[name]))
+	}
+	if len(entry.Fields) > 0 {
+		text += "\n"
+	}
+
 	if cfg.IncludeFingerprint {
 		text += fmt.Sprintf("<b>Уникальный ключ:</b> <code>%s</code>\n\n", fp)
 	}
@@ -42,3 +41,20 @@ func FormatTelegram(entry log_processing.LogEntry, cfg config.FormatConfig) stri
 
 	return text
 }
+
+// LevelEmoji значок уровня в сообщениях telegram; для неизвестных уровней пусто
+func LevelEmoji(level string) string {
+	switch level {
+	case "INFO":
+		return "🟢"
+	case "DEBUG":
+		return "🟡"
+	case "WARN":
+		return "🟠"
+	case "ERROR":
+		return "🔴"
+	case "FATAL":
+		return "🟣"
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package formatter
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+// markup разметка получателя для служебных сообщений (сводки), чтобы один шаблон
+// выглядел нормально и в Telegram (HTML), и в Slack (mrkdwn), и в Mattermost (Markdown)
+type markup struct {
+	esc  func(string) string
+	bold func(string) string
+	code func(string) string
+}
+
+func markupFor(destType string) markup {
+	plain := func(v string) string { return v }
+	switch destType {
+	case "telegram":
+		return markup{
+			esc:  html.EscapeString,
+			bold: func(v string) string { return "<b>" + v + "</b>" },
+			code: func(v string) string { return "<code>" + v + "</code>" },
+		}
+	case "slack":
+		return markup{
+			esc:  EscapeMrkdwn,
+			bold: func(v string) string { return "*" + v + "*" },
+			code: func(v string) string { return "`" + strings.ReplaceAll(v, "`", "'") + "`" },
+		}
+	case "mattermost":
+		return markup{
+			esc:  EscapeMarkdown,
+			bold: func(v string) string { return "**" + v + "**" },
+			code: func(v string) string { return "`" + strings.ReplaceAll(v, "`", "'") + "`" },
+		}
+	case "discord":
+		return markup{
+			esc:  EscapeDiscord,
+			bold: func(v string) string { return "**" + v + "**" },
+			code: func(v string) string { return "`" + strings.ReplaceAll(v, "`", "'") + "`" },
+		}
+	default:
+		return markup{esc: plain, bold: plain, code: plain}
+	}
+}
+
+// notice оборачивает текст служебного сообщения в payload, если получатель ждёт JSON
+func notice(destType, text string) string {
+	switch destType {
+	case "slack", "mattermost":
+		return marshalJSON(map[string]string{"text": text})
+	case "discord":
+		return marshalJSON(discordPayload{Content: truncateRunes(text, discordContentLimit)})
+	case "smtp":
+		return marshalJSON(emailPayload{Text: text, HTML: "<pre>" + html.EscapeString(text) + "</pre>"})
+	default:
+		return text
+	}
+}
+
+// marshalJSON JSON без экранирования & < > в \u00XX, чтобы payload оставался читаемым
+func marshalJSON(v any) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// mrkdwnReplacer управляющие символы Slack mrkdwn, остальное Slack экранировать не умеет
+var mrkdwnReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// EscapeMrkdwn экранирует текст для Slack mrkdwn
+func EscapeMrkdwn(s string) string {
+	return mrkdwnReplacer.Replace(s)
+}
+
+// markdownReplacer символы разметки Markdown в Mattermost экранируются обратным слэшем
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`",
+	"[", `\[`, "]", `\]`, "#", `\#`, "|", `\|`, "<", "&lt;", ">", "&gt;",
+)
+
+// EscapeMarkdown экранирует текст для Markdown Mattermost
+func EscapeMarkdown(s string) string {
+	return markdownReplacer.Replace(s)
+}
@@ -0,0 +1,51 @@
+package formatter
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// renderMessage возвращает текст сообщения: результат format.message_template, если он задан,
+// иначе исходный Message. При ошибке шаблона возвращаем Message, чтобы не терять алерт.
+// Шаблон разбирается один раз в config.Validate; разбор здесь - только для конфига, собранного вручную.
+func renderMessage(entry log_processing.LogEntry, cfg config.FormatConfig) string {
+	if cfg.MessageTemplate == "" {
+		return entry.Message
+	}
+
+	tmpl := cfg.Template()
+	if tmpl == nil {
+		var err error
+		if tmpl, err = template.New("message").Option("missingkey=zero").Parse(cfg.MessageTemplate); err != nil {
+			return entry.Message
+		}
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, entry); err != nil {
+		return entry.Message
+	}
+	return b.String()
+}
+
+// fingerprint ключ дедупликации записи, если он уже посчитан, иначе хэш исходной строки
+func fingerprint(entry log_processing.LogEntry) string {
+	if entry.Fingerprint != "" {
+		return entry.Fingerprint
+	}
+	return protect_from_duplicates.Fingerprint(entry.Raw)
+}
+
+// sortedFieldNames имена извлечённых полей в стабильном порядке
+func sortedFieldNames(fields map[string]string) []string {
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
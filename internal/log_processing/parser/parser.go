@@ -8,7 +8,11 @@ import (
 	"time"
 )
 
-var logLineRegexp = regexp.MustCompile(`^(\S+)\s+\[(DEBUG|INFO|ERROR)\]\s+(.+)$`)
+// Levels уровни, которые понимает парсер; другие уровни в конфиге никогда не совпадут.
+// WARN и FATAL есть и в уровнях PagerDuty/Opsgenie по умолчанию.
+var Levels = []string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+var logLineRegexp = regexp.MustCompile(`^(\S+)\s+\[(` + strings.Join(Levels, "|") + `)\]\s+(.+)$`)
 
 func ParseLine(raw string) (log_processing.LogEntry, error) {
 	raw = strings.TrimSpace(raw)
@@ -55,3 +55,15 @@ func TestParseLine_InvalidTimestamp(t *testing.T) {
 		t.Fatal("ожидается ошибка невалидного времени, получено nil")
 	}
 }
+
+func TestParseLine_AllLevels(t *testing.T) {
+	for _, level := range Levels {
+		entry, err := ParseLine("2026-02-25T17:24:25+03:00 [" + level + "] something happened")
+		if err != nil || entry.Level != level {
+			t.Fatalf("ожидается уровень %s, получено %q (%v)", level, entry.Level, err)
+		}
+	}
+	if _, err := ParseLine("2026-02-25T17:24:25+03:00 [CRITICAL] something happened"); err == nil {
+		t.Fatal("ожидается ошибка для неизвестного уровня")
+	}
+}
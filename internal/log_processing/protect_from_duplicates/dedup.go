@@ -1,8 +1,11 @@
 package protect_from_duplicates
 
 import (
+	"Bug_tracking_bot/internal/log_processing"
 	"crypto/sha256"
 	"encoding/hex"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -20,8 +23,12 @@ func NewDeduplicator(ttl time.Duration) *Deduplicator {
 
 // Allow возвращает true, если лог еще не отправлялся недавно.
 func (d *Deduplicator) Allow(raw string) bool {
+	return d.AllowKey(Fingerprint(raw))
+}
+
+// AllowKey то же, что Allow, но по заранее посчитанному ключу (см. Key).
+func (d *Deduplicator) AllowKey(key string) bool {
 	now := time.Now()
-	key := Fingerprint(raw)
 
 	// периодическая очистка через timeLife
 	for k, t := range d.seen {
@@ -42,3 +49,57 @@ func Fingerprint(raw string) string {
 	sum := sha256.Sum256([]byte(raw))
 	return hex.EncodeToString(sum[:])[:12] // короткий хэш для хранения в мапе
 }
+
+// Key считает ключ дедупликации записи. Если groupBy пустой или в записи нет ни одного
+// из перечисленных полей, ключом остаётся Fingerprint исходной строки.
+// Иначе ключ строится из правила и значений полей, поэтому "order 123 failed" в разное время
+// считается одним и тем же событием.
+func Key(entry log_processing.LogEntry, groupBy []string) string {
+	var b strings.Builder
+	found := false
+	for _, name := range groupBy {
+		v, ok := entry.Fields[name]
+		if ok {
+			found = true
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(v)
+		b.WriteByte('\x00')
+	}
+	if !found {
+		return Fingerprint(entry.Raw)
+	}
+	return Fingerprint(entry.Rule + "\x00" + b.String())
+}
+
+// StableKey ключ «той же самой ошибки» для инцидентов, задач, кнопки Mute, дайджестов и отчётов.
+// Fingerprint по исходной строке для этого не годится: в строке есть время, и каждая строка уникальна.
+// Поэтому, если ключ дедупликации посчитан по group_by, используется он, иначе - правило и нормализованное сообщение.
+func StableKey(entry log_processing.LogEntry) string {
+	if entry.Fingerprint != "" && entry.Fingerprint != Fingerprint(entry.Raw) {
+		return entry.Fingerprint
+	}
+	return Fingerprint(entry.Rule + "\x00" + Normalize(entry.Message))
+}
+
+var (
+	uuidRe   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexRe    = regexp.MustCompile(`(?i)\b(?:0x)?[0-9a-f]{8,}\b`)
+	numberRe = regexp.MustCompile(`\d+(?:\.\d+)?`)
+)
+
+// Normalize приводит сообщение к шаблону: UUID, hex-идентификаторы и числа заменяются заглушками,
+// чтобы "order 123 failed" и "order 456 failed" считались одной ошибкой
+func Normalize(msg string) string {
+	msg = uuidRe.ReplaceAllString(msg, "<uuid>")
+	msg = hexRe.ReplaceAllStringFunc(msg, func(s string) string {
+		// только цифры - обычное число, только буквы - скорее слово
+		if strings.ContainsAny(s, "0123456789") && strings.ContainsAny(strings.ToLower(s), "abcdef") {
+			return "<hex>"
+		}
+		return s
+	})
+	msg = numberRe.ReplaceAllString(msg, "<n>")
+	return strings.Join(strings.Fields(msg), " ")
+}
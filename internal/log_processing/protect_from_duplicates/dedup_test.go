@@ -1,6 +1,7 @@
 package protect_from_duplicates
 
 import (
+	"Bug_tracking_bot/internal/log_processing"
 	"testing"
 	"time"
 )
@@ -57,3 +58,65 @@ func TestFingerprint_SameInput_SameOutput(t *testing.T) {
 		t.Fatalf("ожидается уникальный ключ длины 12, получено %d", len(fp1))
 	}
 }
+
+func TestKey_GroupByFields(t *testing.T) {
+	a := log_processing.LogEntry{
+		Raw:    "2026-02-25T17:24:25+03:00 [ERROR] order 123 failed",
+		Rule:   `order (?P<order_id>\d+) failed`,
+		Fields: map[string]string{"order_id": "123"},
+	}
+	b := a
+	b.Raw = "2026-02-25T17:30:00+03:00 [ERROR] order 123 failed"
+
+	if Key(a, []string{"order_id"}) != Key(b, []string{"order_id"}) {
+		t.Fatal("ожидается одинаковый ключ для одного order_id")
+	}
+
+	c := a
+	c.Fields = map[string]string{"order_id": "456"}
+	if Key(a, []string{"order_id"}) == Key(c, []string{"order_id"}) {
+		t.Fatal("ожидаются разные ключи для разных order_id")
+	}
+}
+
+func TestKey_NoFields_FallbackToRaw(t *testing.T) {
+	e := log_processing.LogEntry{Raw: "2026-02-25T17:24:25+03:00 [ERROR] Error processing request"}
+
+	if Key(e, []string{"order_id"}) != Fingerprint(e.Raw) {
+		t.Fatal("без полей ключ должен совпадать с Fingerprint исходной строки")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"order 123 failed after 1.5s":                         "order <n> failed after <n>s",
+		"user 550e8400-e29b-41d4-a716-446655440000 not found": "user <uuid> not found",
+		"commit deadbeef42 rejected,  retry   later":          "commit <hex> rejected, retry later",
+		"connection refused":                                  "connection refused",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, ожидается %q", in, got, want)
+		}
+	}
+}
+
+func TestStableKey(t *testing.T) {
+	a := log_processing.LogEntry{Rule: "orders", Message: "order 1 failed", Raw: "2026-03-01T10:00:00Z [ERROR] order 1 failed"}
+	b := log_processing.LogEntry{Rule: "orders", Message: "order 2 failed", Raw: "2026-03-01T10:05:00Z [ERROR] order 2 failed"}
+	a.Fingerprint = Key(a, nil)
+	b.Fingerprint = Key(b, nil)
+
+	if a.Fingerprint == b.Fingerprint {
+		t.Fatal("без group_by ключи дедупликации строк с разным временем различаются")
+	}
+	if StableKey(a) != StableKey(b) {
+		t.Fatal("без group_by StableKey должен совпадать для одной и той же ошибки в разное время")
+	}
+
+	c := log_processing.LogEntry{Rule: "orders", Message: "order 1 failed", Fields: map[string]string{"order_id": "1"}}
+	c.Fingerprint = Key(c, []string{"order_id"})
+	if StableKey(c) != c.Fingerprint {
+		t.Fatal("с group_by StableKey должен совпадать с ключом дедупликации")
+	}
+}
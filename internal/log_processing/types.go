@@ -4,8 +4,14 @@ import "time"
 
 // LogEntry Структура записи лога после парсинга
 type LogEntry struct {
-	Timestamp time.Time
-	Level     string
-	Message   string
-	Raw       string
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`
+	Message   string    `json:"message"`
+	Raw       string    `json:"raw"`
+	Source    string    `json:"source,omitempty"` // Файл, из которого прочитана строка
+
+	// Заполняются matcher'ом и дедупликатором после совпадения с правилом
+	Rule        string            `json:"rule,omitempty"`        // Правило, с которым совпала запись
+	Fields      map[string]string `json:"fields,omitempty"`      // Именованные группы regex, например (?P<order_id>\d+)
+	Fingerprint string            `json:"fingerprint,omitempty"` // Ключ дедупликации
 }
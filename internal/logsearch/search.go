@@ -0,0 +1,246 @@
+package logsearch
+
+import (
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Поиск по файлу лога и его ротированным копиям (app.log.1, app.log-20260301, app.log.2.gz) для /tail и /grep
+
+const (
+	maxLineLen  = 2000     // длиннее - строка обрезается
+	tailChunk   = 64 << 10 // по сколько байт читаем файл с конца
+	scanBufSize = 1 << 20  // самая длинная строка, которую читает Scanner
+	maxTailRead = 16 << 20 // больше с конца файла не читаем, даже если строк меньше n
+)
+
+// Result найденные строки: не больше limit последних, Total - сколько подошло всего
+type Result struct {
+	Lines []string
+	Total int
+	Files int // сколько файлов просмотрено
+}
+
+// Files текущий файл и его ротированные копии, от старых к новым; текущий последний
+func Files(path string) ([]string, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога логов: %w", err)
+	}
+
+	type file struct {
+		path  string
+		mtime time.Time
+	}
+	var rotated []file
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == base || !(strings.HasPrefix(name, base+".") || strings.HasPrefix(name, base+"-")) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		rotated = append(rotated, file{filepath.Join(dir, name), info.ModTime()})
+	}
+	sort.Slice(rotated, func(i, j int) bool { return rotated[i].mtime.Before(rotated[j].mtime) })
+
+	files := make([]string, 0, len(rotated)+1)
+	for _, f := range rotated {
+		files = append(files, f.path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("файл лога %s не найден", path)
+	}
+	return files, nil
+}
+
+// Tail последние n строк: сначала текущий файл, если в нём меньше n строк - ротированные копии
+func Tail(path string, n int) (Result, error) {
+	files, err := Files(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var res Result
+	for i := len(files) - 1; i >= 0 && len(res.Lines) < n; i-- {
+		lines, cut, err := tailFile(files[i], n-len(res.Lines))
+		if err != nil {
+			return Result{}, err
+		}
+		res.Lines = append(lines, res.Lines...)
+		res.Files++
+		if cut {
+			break
+		}
+	}
+	res.Total = len(res.Lines)
+	return res, nil
+}
+
+// tailFile последние n строк файла; обычный файл читается с конца, сжатый - целиком.
+// cut - чтение остановилось на maxTailRead, не дойдя до начала файла: строк может быть меньше n,
+// но брать недостающие из более старых файлов нельзя - между ними остался бы пропуск.
+func tailFile(path string, n int) (lines []string, cut bool, err error) {
+	if strings.HasSuffix(path, ".gz") {
+		var ring []string
+		err = scanFile(context.Background(), path, func(line string) {
+			ring = append(ring, line)
+			if len(ring) > n {
+				ring = ring[1:]
+			}
+		})
+		return ring, false, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("ошибка открытия %s: %w", path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, fmt.Errorf("ошибка открытия %s: %w", path, err)
+	}
+
+	// читаем блоками с конца, пока не наберётся n+1 перевод строки (первая строка блока может быть неполной)
+	var buf []byte
+	pos := info.Size()
+	for pos > 0 && len(buf) < maxTailRead && bytes.Count(buf, []byte{'\n'}) <= n {
+		size := min(int64(tailChunk), pos)
+		pos -= size
+		chunk := make([]byte, size)
+		if _, err := f.ReadAt(chunk, pos); err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, fmt.Errorf("ошибка чтения %s: %w", path, err)
+		}
+		buf = append(chunk, buf...)
+	}
+
+	lines = strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	if pos > 0 {
+		// файл прочитан не с начала: первая строка блока может быть обрезана
+		lines = lines[1:]
+	}
+	cut = pos > 0 && len(lines) < n
+	if len(lines) == 0 || len(lines) == 1 && lines[0] == "" {
+		return nil, cut, nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i := range lines {
+		lines[i] = truncate(strings.TrimRight(lines[i], "\r"))
+	}
+	return lines, cut, nil
+}
+
+// Grep строки, совпавшие с re, со временем не раньше since, из текущего и ротированных файлов.
+// Строки без времени (продолжение stack trace) получают время предыдущей строки с временем.
+// Возвращаются не больше limit последних совпадений.
+func Grep(ctx context.Context, path string, re *regexp.Regexp, since time.Time, limit int) (Result, error) {
+	files, err := Files(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var res Result
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.ModTime().Before(since) {
+			continue // файл целиком старше окна
+		}
+		res.Files++
+
+		var last time.Time
+		err := scanFile(ctx, file, func(line string) {
+			if entry, err := parser.ParseLine(line); err == nil {
+				last = entry.Timestamp
+			}
+			if last.Before(since) || !re.MatchString(line) {
+				return
+			}
+			res.Total++
+			res.Lines = append(res.Lines, truncate(line))
+			if len(res.Lines) > limit {
+				res.Lines = res.Lines[1:]
+			}
+		})
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	return res, nil
+}
+
+// scanFile построчно читает файл, распаковывая .gz
+func scanFile(ctx context.Context, path string, fn func(line string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("ошибка распаковки %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), scanBufSize)
+	for i := 0; sc.Scan(); i++ {
+		if i%10000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		fn(strings.TrimRight(sc.Text(), "\r"))
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	return nil
+}
+
+func truncate(line string) string {
+	if r := []rune(line); len(r) > maxLineLen {
+		return string(r[:maxLineLen]) + "…"
+	}
+	return line
+}
+
+// ParseSince "30m", "2h", "7d" - окно поиска назад от now
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	d, err := time.ParseDuration(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		// time.ParseDuration не знает дней
+		d, err = time.ParseDuration(days + "h")
+		d *= 24
+	}
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("неверное окно %q, ожидается например 30m, 2h или 7d", s)
+	}
+	return now.Add(-d), nil
+}
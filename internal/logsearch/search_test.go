@@ -0,0 +1,128 @@
+package logsearch
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLogs пишет app.log.1.gz (старый), app.log.1 и app.log (текущий) с разным временем изменения
+func writeLogs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	line := func(min int, level, msg string) string {
+		return fmt.Sprintf("%s [%s] %s\n", base.Add(time.Duration(min)*time.Minute).Format(time.RFC3339), level, msg)
+	}
+
+	gzPath := path + ".2.gz"
+	f, err := os.Create(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(line(0, "ERROR", "db timeout old")))
+	gz.Close()
+	f.Close()
+
+	os.WriteFile(path+".1", []byte(line(30, "ERROR", "db timeout")+"\tat stack db\n"+line(40, "INFO", "ok")), 0o644)
+	os.WriteFile(path, []byte(line(60, "ERROR", "db timeout new")+line(61, "INFO", "done")), 0o644)
+
+	os.Chtimes(gzPath, base, base)
+	os.Chtimes(path+".1", base.Add(40*time.Minute), base.Add(40*time.Minute))
+	os.Chtimes(path, base.Add(61*time.Minute), base.Add(61*time.Minute))
+	return path
+}
+
+func TestFiles_RotatedOrder(t *testing.T) {
+	path := writeLogs(t)
+	files, err := Files(path)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	want := []string{path + ".2.gz", path + ".1", path}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Fatalf("ожидается %v, получено %v", want, files)
+	}
+}
+
+func TestTail_AcrossRotation(t *testing.T) {
+	path := writeLogs(t)
+
+	res, err := Tail(path, 1)
+	if err != nil || len(res.Lines) != 1 || !strings.Contains(res.Lines[0], "done") {
+		t.Fatalf("ожидается последняя строка текущего файла, получено %v %v", res.Lines, err)
+	}
+
+	res, err = Tail(path, 4)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	if len(res.Lines) != 4 || !strings.Contains(res.Lines[0], "stack db") || res.Files != 2 {
+		t.Fatalf("ожидаются 4 строки из двух файлов, получено %d файлов: %v", res.Files, res.Lines)
+	}
+}
+
+func TestTail_DropsLineCutByReadLimit(t *testing.T) {
+	path := writeLogs(t)
+	// строка длиннее maxTailRead: её начало не прочитано, показывать обрезок и строки старых файлов нельзя
+	long := strings.Repeat("x", maxTailRead+tailChunk)
+	if err := os.WriteFile(path, []byte("2026-03-01T12:00:00Z [ERROR] "+long+"\n2026-03-01T12:00:01Z [INFO] last\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Tail(path, 5)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	if len(res.Lines) != 1 || !strings.HasSuffix(res.Lines[0], "last") || res.Files != 1 {
+		t.Fatalf("ожидается только последняя целая строка текущего файла, получено %d файлов: %v", res.Files, res.Lines)
+	}
+}
+
+func TestGrep_WindowAndLimit(t *testing.T) {
+	path := writeLogs(t)
+	re := regexp.MustCompile(`db`)
+	since := time.Date(2026, 3, 1, 10, 20, 0, 0, time.UTC)
+
+	res, err := Grep(context.Background(), path, re, since, 10)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	// старый .gz вне окна, строка stack trace получает время предыдущей строки
+	if res.Total != 3 || res.Files != 2 {
+		t.Fatalf("ожидается 3 совпадения в двух файлах, получено %d в %d: %v", res.Total, res.Files, res.Lines)
+	}
+
+	res, _ = Grep(context.Background(), path, re, time.Time{}, 2)
+	if res.Total != 4 || len(res.Lines) != 2 || !strings.Contains(res.Lines[1], "db timeout new") {
+		t.Fatalf("ожидаются 2 последних из 4 совпадений, получено %d: %v", res.Total, res.Lines)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"30m": now.Add(-30 * time.Minute),
+		"2h":  now.Add(-2 * time.Hour),
+		"7d":  now.Add(-7 * 24 * time.Hour),
+	} {
+		got, err := ParseSince(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("%s: ожидается %v, получено %v %v", in, want, got, err)
+		}
+	}
+	for _, bad := range []string{"", "abc", "-1h", "xd"} {
+		if _, err := ParseSince(bad, now); err == nil {
+			t.Fatalf("%q: ожидается ошибка", bad)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package outbox
+
+import (
+	"Bug_tracking_bot/internal/sender"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// compactEvery после скольких подтверждённых записей переписываем файл
+const compactEvery = 100
+
+// Record сообщение, ожидающее доставки
+type Record struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	sender.Message
+}
+
+// line строка журнала: add добавляет сообщение, ack/drop убирают его из очереди
+type line struct {
+	Op string `json:"op"` // add | ack | drop
+	Record
+}
+
+// Outbox локальная очередь сообщений на диске (append-only журнал в JSONL).
+// Переживает недоступность отправителя и перезапуск бота: при открытии журнал
+// проигрывается заново и все неподтверждённые сообщения снова оказываются в очереди.
+type Outbox struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	pending []Record // в порядке добавления
+	nextID  uint64
+	removed int // ack/drop с момента последнего сжатия
+}
+
+func Open(path string) (*Outbox, error) {
+	o := &Outbox{path: path, nextID: 1}
+
+	if err := o.replay(); err != nil {
+		return nil, err
+	}
+
+	// Сразу сжимаем журнал, чтобы не тащить подтверждённые записи между перезапусками
+	if err := o.compactLocked(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *Outbox) replay() error {
+	f, err := os.Open(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка открытия outbox: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	removed := make(map[uint64]bool)
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var l line
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			// недописанная строка после аварийного завершения
+			continue
+		}
+		if l.ID >= o.nextID {
+			o.nextID = l.ID + 1
+		}
+		switch l.Op {
+		case "add":
+			records = append(records, l.Record)
+		case "ack", "drop":
+			removed[l.ID] = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения outbox: %w", err)
+	}
+
+	for _, r := range records {
+		if !removed[r.ID] {
+			o.pending = append(o.pending, r)
+		}
+	}
+	return nil
+}
+
+// Enqueue добавляет сообщение в конец очереди и сбрасывает запись на диск
+func (o *Outbox) Enqueue(msg sender.Message) (Record, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	r := Record{ID: o.nextID, CreatedAt: time.Now(), Message: msg}
+	if err := o.appendLocked(line{Op: "add", Record: r}); err != nil {
+		return Record{}, err
+	}
+	o.nextID++
+	o.pending = append(o.pending, r)
+	return r, nil
+}
+
+// Peek самое старое неподтверждённое сообщение
+func (o *Outbox) Peek() (Record, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if len(o.pending) == 0 {
+		return Record{}, false
+	}
+	return o.pending[0], true
+}
+
+// Ack сообщение доставлено
+func (o *Outbox) Ack(id uint64) error {
+	return o.remove(id, "ack")
+}
+
+// Drop сообщение больше не доставляем (устарело или постоянная ошибка)
+func (o *Outbox) Drop(id uint64) error {
+	return o.remove(id, "drop")
+}
+
+func (o *Outbox) remove(id uint64, op string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	idx := -1
+	for i, r := range o.pending {
+		if r.ID == id {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil
+	}
+
+	if err := o.appendLocked(line{Op: op, Record: Record{ID: id}}); err != nil {
+		return err
+	}
+	o.pending = append(o.pending[:idx], o.pending[idx+1:]...)
+	o.removed++
+
+	if o.removed >= compactEvery {
+		return o.compactLocked()
+	}
+	return nil
+}
+
+// Len сколько сообщений ждёт доставки
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.f == nil {
+		return nil
+	}
+	err := o.f.Close()
+	o.f = nil
+	return err
+}
+
+func (o *Outbox) appendLocked(l line) error {
+	if o.f == nil {
+		f, err := os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("ошибка открытия outbox: %w", err)
+		}
+		o.f = f
+	}
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования записи outbox: %w", err)
+	}
+	b = append(b, '\n')
+
+	if _, err := o.f.Write(b); err != nil {
+		return fmt.Errorf("ошибка записи в outbox: %w", err)
+	}
+	if err := o.f.Sync(); err != nil {
+		return fmt.Errorf("ошибка сброса outbox на диск: %w", err)
+	}
+	return nil
+}
+
+// compactLocked переписывает журнал, оставляя только неподтверждённые сообщения
+func (o *Outbox) compactLocked() error {
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла outbox: %w", err)
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, r := range o.pending {
+		if err := enc.Encode(line{Op: "add", Record: r}); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return fmt.Errorf("ошибка сжатия outbox: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сжатия outbox: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сжатия outbox: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сжатия outbox: %w", err)
+	}
+
+	if o.f != nil {
+		o.f.Close()
+		o.f = nil
+	}
+	if err := os.Rename(tmp.Name(), o.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сжатия outbox: %w", err)
+	}
+
+	o.removed = 0
+	return nil
+}
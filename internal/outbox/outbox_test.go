@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"Bug_tracking_bot/internal/sender"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOutbox_PendingSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+
+	ob, err := Open(path)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	first, _ := ob.Enqueue(sender.Message{Text: "первое"})
+	if _, err := ob.Enqueue(sender.Message{Text: "второе"}); err != nil {
+		t.Fatalf("ошибка добавления: %v", err)
+	}
+	if err := ob.Ack(first.ID); err != nil {
+		t.Fatalf("ошибка подтверждения: %v", err)
+	}
+	ob.Close()
+
+	// имитируем недописанную строку после аварийного завершения
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString(`{"op":"add","id":9,"te`)
+	f.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	defer reopened.Close()
+
+	rec, ok := reopened.Peek()
+	if !ok || rec.Text != "второе" || reopened.Len() != 1 {
+		t.Fatalf("ожидается одно неподтверждённое сообщение %q, получено %+v (len=%d)", "второе", rec, reopened.Len())
+	}
+
+	next, _ := reopened.Enqueue(sender.Message{Text: "третье"})
+	if next.ID <= rec.ID {
+		t.Fatalf("ID должны расти после перезапуска: %d <= %d", next.ID, rec.ID)
+	}
+
+	// после открытия журнал сжат: подтверждённых записей в нём нет
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), "первое") {
+		t.Fatal("подтверждённое сообщение должно исчезнуть из журнала после сжатия")
+	}
+}
+
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []string
+	errs []error // ошибки для первых вызовов
+}
+
+func (s *fakeSender) Send(_ context.Context, msg sender.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	s.sent = append(s.sent, msg.Text)
+	return nil
+}
+
+func TestWorker_DeliversInOrderAndDropsExpired(t *testing.T) {
+	ob, err := Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	defer ob.Close()
+
+	old, _ := ob.Enqueue(sender.Message{Text: "устаревшее"})
+	ob.pending[0].CreatedAt = old.CreatedAt.Add(-2 * time.Hour)
+	ob.Enqueue(sender.Message{Text: "a"})
+	ob.Enqueue(sender.Message{Text: "b"})
+
+	snd := &fakeSender{errs: []error{&sender.APIError{StatusCode: 502}}}
+	var done []string
+	w := NewWorker(ob, snd, Options{MaxAge: time.Hour, SendTimeout: time.Second, RetryInterval: time.Millisecond,
+		Done: func(msg sender.Message, err error) { done = append(done, fmt.Sprintf("%s:%v", msg.Text, err)) }})
+
+	if w.drain(context.Background()) {
+		t.Fatal("первая отправка падает с 502, ожидается false")
+	}
+	if !w.drain(context.Background()) {
+		t.Fatal("ожидается успешная доставка")
+	}
+
+	if strings.Join(snd.sent, ",") != "a,b" || ob.Len() != 0 {
+		t.Fatalf("ожидается доставка a,b по порядку без устаревшего, получено %v (в очереди %d)", snd.sent, ob.Len())
+	}
+	// временная ошибка 502 - не итог, о ней Done не сообщает
+	if got := strings.Join(done, ","); got != "устаревшее:старше max_age 1h0m0s,a:<nil>,b:<nil>" {
+		t.Fatalf("неверные итоги доставки: %s", got)
+	}
+}
+
+func TestWorker_PermanentErrorDropped(t *testing.T) {
+	ob, err := Open(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	defer ob.Close()
+	ob.Enqueue(sender.Message{Text: "a"})
+
+	snd := &fakeSender{errs: []error{errors.New("chat not found")}}
+	var doneErr error
+	w := NewWorker(ob, snd, Options{SendTimeout: time.Second, Done: func(_ sender.Message, err error) { doneErr = err }})
+
+	if !w.drain(context.Background()) || ob.Len() != 0 {
+		t.Fatal("сообщение с постоянной ошибкой должно быть удалено, очередь не должна вставать")
+	}
+	if doneErr == nil || doneErr.Error() != "chat not found" {
+		t.Fatalf("итог должен содержать причину удаления, получено %v", doneErr)
+	}
+}
@@ -0,0 +1,188 @@
+package outbox
+
+import (
+	"Bug_tracking_bot/internal/sender"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Options параметры доставки; могут меняться при hot reload через Worker.Update
+type Options struct {
+	MaxAge        time.Duration // старше - удаляем без отправки; 0 = без ограничения
+	SendTimeout   time.Duration // на одну отправку, включая повторы внутри sender; 0 = без ограничения
+	RetryInterval time.Duration // пауза перед новой попыткой, если отправка не удалась
+	// Done вызывается из goroutine worker'а с окончательным итогом сообщения: nil - доставлено,
+	// иначе причина удаления без доставки. Не должен блокироваться. nil - не вызывается.
+	Done func(msg sender.Message, err error)
+}
+
+// Worker по порядку доставляет сообщения из Outbox и подтверждает их после успешной отправки
+type Worker struct {
+	ob     *Outbox
+	wake   chan struct{}
+	mu     sync.Mutex
+	sender sender.Sender
+	opts   Options
+	health Health
+}
+
+// Health состояние доставки для /status и отчётов
+type Health struct {
+	Pending     int       // сообщений в outbox
+	Sent        int       // доставлено с момента запуска
+	Failed      int       // неудачных попыток отправки с момента запуска
+	Dropped     int       // удалено без доставки (постоянная ошибка или max_age)
+	LastSuccess time.Time // время последней успешной отправки
+	LastError   string    // последняя ошибка отправки
+	LastErrorAt time.Time
+}
+
+func NewWorker(ob *Outbox, snd sender.Sender, opts Options) *Worker {
+	return &Worker{ob: ob, wake: make(chan struct{}, 1), sender: snd, opts: opts}
+}
+
+// Update подменяет отправителя и параметры (после перезагрузки конфига)
+func (w *Worker) Update(snd sender.Sender, opts Options) {
+	w.mu.Lock()
+	w.sender = snd
+	w.opts = opts
+	w.mu.Unlock()
+	w.Notify()
+}
+
+// Notify будит worker после добавления сообщения
+func (w *Worker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Health текущее состояние доставки
+func (w *Worker) Health() Health {
+	w.mu.Lock()
+	h := w.health
+	w.mu.Unlock()
+	h.Pending = w.ob.Len()
+	return h
+}
+
+// record учитывает результат отправки в Health; dropped - сообщение удалено без доставки
+func (w *Worker) record(err error, dropped bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch {
+	case err != nil:
+		w.health.Failed++
+		w.health.LastError = err.Error()
+		w.health.LastErrorAt = time.Now()
+	case !dropped:
+		w.health.Sent++
+		w.health.LastSuccess = time.Now()
+	}
+	if dropped {
+		w.health.Dropped++
+	}
+}
+
+func (w *Worker) current() (sender.Sender, Options) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sender, w.opts
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		if !w.drain(ctx) {
+			// отправка не удалась, ждём перед новой попыткой
+			_, opts := w.current()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(opts.RetryInterval):
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.wake:
+		}
+	}
+}
+
+// drain отправляет очередь, пока она не опустеет; false - если отправка не удалась и надо подождать
+func (w *Worker) drain(ctx context.Context) bool {
+	dropped := 0
+	defer func() {
+		if dropped > 0 {
+			_, opts := w.current()
+			log.Printf("Outbox: удалено алертов старше %s: %d", opts.MaxAge, dropped)
+		}
+	}()
+
+	for ctx.Err() == nil {
+		rec, ok := w.ob.Peek()
+		if !ok {
+			return true
+		}
+
+		snd, opts := w.current()
+		if opts.MaxAge > 0 && time.Since(rec.CreatedAt) > opts.MaxAge {
+			if err := w.ob.Drop(rec.ID); err != nil {
+				log.Printf("Outbox: ошибка удаления записи: %v", err)
+				return false
+			}
+			dropped++
+			w.record(nil, true)
+			opts.done(rec.Message, fmt.Errorf("старше max_age %s", opts.MaxAge))
+			continue
+		}
+
+		sendCtx, cancel := ctx, context.CancelFunc(func() {})
+		if opts.SendTimeout > 0 {
+			sendCtx, cancel = context.WithTimeout(ctx, opts.SendTimeout)
+		}
+		err := snd.Send(sendCtx, rec.Message)
+		cancel()
+
+		if !(errors.Is(err, context.Canceled) && ctx.Err() != nil) {
+			w.record(err, err != nil && !sender.IsRetryable(err) && !errors.Is(err, context.DeadlineExceeded))
+		}
+
+		switch {
+		case err == nil:
+			if err := w.ob.Ack(rec.ID); err != nil {
+				log.Printf("Outbox: ошибка подтверждения записи: %v", err)
+				return false
+			}
+			opts.done(rec.Message, nil)
+		case errors.Is(err, context.Canceled) && ctx.Err() != nil:
+			// остановка бота, сообщение останется в outbox до следующего запуска
+			return true
+		case sender.IsRetryable(err) || errors.Is(err, context.DeadlineExceeded):
+			log.Printf("Ошибка отправки, сообщение осталось в outbox (в очереди %d): %v", w.ob.Len(), err)
+			return false
+		default:
+			// постоянная ошибка: повторять бессмысленно, иначе очередь встанет
+			log.Printf("Ошибка отправки, сообщение удалено из outbox: %v", err)
+			if err := w.ob.Drop(rec.ID); err != nil {
+				log.Printf("Outbox: ошибка удаления записи: %v", err)
+				return false
+			}
+			opts.done(rec.Message, err)
+		}
+	}
+	return true
+}
+
+func (o Options) done(msg sender.Message, err error) {
+	if o.Done != nil {
+		o.Done(msg, err)
+	}
+}
@@ -71,3 +71,8 @@ func (r *FileReader) ReadNewLines() ([]string, error) {
 
 	return lines, nil
 }
+
+// Offset сколько байт файла уже прочитано
+func (r *FileReader) Offset() int64 {
+	return r.alreadyRead
+}
@@ -0,0 +1,364 @@
+package report
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Счётчики для регулярного отчёта о состоянии логов и статистике алертов.
+// Копятся основным циклом за период между отчётами и сохраняются в JSON, чтобы переживать перезапуск.
+
+const (
+	topN            = 10                  // сколько строк в топах ошибок и правил
+	maxNewShown     = 20                  // сколько новых fingerprint перечислять в отчёте
+	maxErrorKeys    = 5000                // больше разных сообщений ошибок не храним, остальные считаются в «прочих»
+	maxFingerprints = 10000               // больше известных алертов не храним, вытесняется давно не встречавшийся
+	fingerprintTTL  = 30 * 24 * time.Hour // fingerprint без повторов дольше этого забывается и снова считается новым
+	otherErrorsName = "…прочие"
+)
+
+// Stats счётчики текущего периода
+type Stats struct {
+	Since        time.Time                 `json:"since"` // начало периода
+	Sources      map[string]*SourceStats   `json:"sources"`
+	Levels       map[string]int            `json:"levels"`
+	Errors       map[string]int            `json:"errors"` // нормализованное сообщение ERROR -> сколько раз
+	Rules        map[string]int            `json:"rules"`  // сработавшее правило -> сколько раз
+	Delivery     map[string]*DeliveryStats `json:"delivery"`
+	Fingerprints map[string]*Fingerprint   `json:"fingerprints"` // известные алерты по StableKey, между периодами не сбрасываются
+}
+
+// SourceStats счётчики одного файла лога
+type SourceStats struct {
+	Lines         int `json:"lines"`
+	ParseFailures int `json:"parse_failures"`
+}
+
+// DeliveryStats ошибки доставки одного получателя
+type DeliveryStats struct {
+	Failed  int `json:"failed"`  // неудачных попыток
+	Dropped int `json:"dropped"` // сообщений, удалённых без доставки
+}
+
+// Fingerprint когда алерт встретился впервые и в последний раз
+type Fingerprint struct {
+	Rule      string    `json:"rule,omitempty"`
+	Message   string    `json:"message"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Collector копит счётчики; используется только из основного цикла.
+// Методы можно вызывать у nil - тогда отчёт выключен и ничего не считается.
+type Collector struct {
+	path  string
+	stats Stats
+	dirty bool
+	prev  map[string]DeliveryStats // последние значения счётчиков Worker.Health, от них считается прирост
+}
+
+// Open загружает счётчики из файла; отсутствующий файл - период начинается с now
+func Open(path string, now time.Time) (*Collector, error) {
+	c := &Collector{path: path, prev: make(map[string]DeliveryStats)}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("ошибка чтения файла статистики: %w", err)
+	case len(strings.TrimSpace(string(b))) > 0:
+		if err := json.Unmarshal(b, &c.stats); err != nil {
+			return nil, fmt.Errorf("ошибка декодирования файла статистики: %w", err)
+		}
+	}
+	if c.stats.Since.IsZero() {
+		c.stats.Since = now
+		c.dirty = true
+	}
+	c.stats.init()
+	return c, nil
+}
+
+func (s *Stats) init() {
+	if s.Sources == nil {
+		s.Sources = make(map[string]*SourceStats)
+	}
+	if s.Levels == nil {
+		s.Levels = make(map[string]int)
+	}
+	if s.Errors == nil {
+		s.Errors = make(map[string]int)
+	}
+	if s.Rules == nil {
+		s.Rules = make(map[string]int)
+	}
+	if s.Delivery == nil {
+		s.Delivery = make(map[string]*DeliveryStats)
+	}
+	if s.Fingerprints == nil {
+		s.Fingerprints = make(map[string]*Fingerprint)
+	}
+}
+
+func (c *Collector) Path() string {
+	return c.path
+}
+
+// Since начало текущего периода
+func (c *Collector) Since() time.Time {
+	return c.stats.Since
+}
+
+func (c *Collector) source(name string) *SourceStats {
+	s, ok := c.stats.Sources[name]
+	if !ok {
+		s = &SourceStats{}
+		c.stats.Sources[name] = s
+	}
+	return s
+}
+
+// Lines прочитано n строк из source
+func (c *Collector) Lines(source string, n int) {
+	if c == nil || n == 0 {
+		return
+	}
+	c.source(source).Lines += n
+	c.dirty = true
+}
+
+// ParseFailure строка не разобрана парсером
+func (c *Collector) ParseFailure(source string) {
+	if c == nil {
+		return
+	}
+	c.source(source).ParseFailures++
+	c.dirty = true
+}
+
+// Parsed разобранная строка: считается её уровень, ошибки - по нормализованному сообщению
+func (c *Collector) Parsed(entry log_processing.LogEntry) {
+	if c == nil {
+		return
+	}
+	level := strings.ToUpper(entry.Level)
+	c.stats.Levels[level]++
+	if level == "ERROR" {
+		key := protect_from_duplicates.Normalize(entry.Message)
+		if _, ok := c.stats.Errors[key]; !ok && len(c.stats.Errors) >= maxErrorKeys {
+			key = otherErrorsName
+		}
+		c.stats.Errors[key]++
+	}
+	c.dirty = true
+}
+
+// Alert сработало правило; запись с Fingerprint, до silences и дедупликации.
+// Алерт учитывается по StableKey: ключ дедупликации без group_by уникален для каждой строки,
+// и каждая строка считалась бы новой.
+func (c *Collector) Alert(entry log_processing.LogEntry, now time.Time) {
+	if c == nil {
+		return
+	}
+	if entry.Rule != "" {
+		c.stats.Rules[entry.Rule]++
+	}
+	key := protect_from_duplicates.StableKey(entry)
+	if fp, ok := c.stats.Fingerprints[key]; ok {
+		fp.LastSeen = now
+	} else {
+		if len(c.stats.Fingerprints) >= maxFingerprints {
+			c.evictOldestFingerprint()
+		}
+		c.stats.Fingerprints[key] = &Fingerprint{
+			Rule:      entry.Rule,
+			Message:   protect_from_duplicates.Normalize(entry.Message),
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+	}
+	c.dirty = true
+}
+
+// evictOldestFingerprint забывает алерт, который дольше всех не встречался, как по истечении fingerprintTTL
+func (c *Collector) evictOldestFingerprint() {
+	var oldest string
+	for key, fp := range c.stats.Fingerprints {
+		if oldest == "" || fp.LastSeen.Before(c.stats.Fingerprints[oldest].LastSeen) {
+			oldest = key
+		}
+	}
+	delete(c.stats.Fingerprints, oldest)
+}
+
+// Delivery учитывает прирост ошибок доставки по счётчикам Worker.Health, которые растут с момента запуска.
+// Если счётчик уменьшился, очередь получателя пересоздана и считается с нуля.
+func (c *Collector) Delivery(dest string, failed, dropped int) {
+	if c == nil {
+		return
+	}
+	prev := c.prev[dest]
+	c.prev[dest] = DeliveryStats{Failed: failed, Dropped: dropped}
+	if failed < prev.Failed || dropped < prev.Dropped {
+		prev = DeliveryStats{}
+	}
+	df, dd := failed-prev.Failed, dropped-prev.Dropped
+	if df == 0 && dd == 0 {
+		return
+	}
+	d, ok := c.stats.Delivery[dest]
+	if !ok {
+		d = &DeliveryStats{}
+		c.stats.Delivery[dest] = d
+	}
+	d.Failed += df
+	d.Dropped += dd
+	c.dirty = true
+}
+
+// Count строка топа
+type Count struct {
+	Name  string
+	Count int
+}
+
+// SourceLine счётчики файла лога в отчёте
+type SourceLine struct {
+	Source string
+	SourceStats
+}
+
+// DeliveryLine ошибки доставки получателя в отчёте
+type DeliveryLine struct {
+	Destination string
+	DeliveryStats
+}
+
+// NewFingerprint алерт, впервые встреченный за период
+type NewFingerprint struct {
+	Key string
+	Fingerprint
+}
+
+// Report отчёт за период [From, To)
+type Report struct {
+	From, To        time.Time
+	Sources         []SourceLine
+	Levels          []Count
+	TopErrors       []Count
+	TopRules        []Count
+	NewFingerprints []NewFingerprint // не больше maxNewShown, самые ранние
+	NewTotal        int
+	Delivery        []DeliveryLine
+}
+
+// Build отчёт по счётчикам текущего периода, до now
+func (c *Collector) Build(now time.Time) Report {
+	st := &c.stats
+	r := Report{
+		From:      st.Since,
+		To:        now,
+		Levels:    sortedCounts(st.Levels, 0),
+		TopErrors: sortedCounts(st.Errors, topN),
+		TopRules:  sortedCounts(st.Rules, topN),
+	}
+
+	for name, s := range st.Sources {
+		r.Sources = append(r.Sources, SourceLine{Source: name, SourceStats: *s})
+	}
+	sort.Slice(r.Sources, func(i, j int) bool { return r.Sources[i].Source < r.Sources[j].Source })
+
+	for name, d := range st.Delivery {
+		r.Delivery = append(r.Delivery, DeliveryLine{Destination: name, DeliveryStats: *d})
+	}
+	sort.Slice(r.Delivery, func(i, j int) bool { return r.Delivery[i].Destination < r.Delivery[j].Destination })
+
+	for key, fp := range st.Fingerprints {
+		if !fp.FirstSeen.Before(st.Since) {
+			r.NewFingerprints = append(r.NewFingerprints, NewFingerprint{Key: key, Fingerprint: *fp})
+		}
+	}
+	sort.Slice(r.NewFingerprints, func(i, j int) bool {
+		a, b := r.NewFingerprints[i], r.NewFingerprints[j]
+		if !a.FirstSeen.Equal(b.FirstSeen) {
+			return a.FirstSeen.Before(b.FirstSeen)
+		}
+		return a.Key < b.Key
+	})
+	r.NewTotal = len(r.NewFingerprints)
+	if len(r.NewFingerprints) > maxNewShown {
+		r.NewFingerprints = r.NewFingerprints[:maxNewShown]
+	}
+	return r
+}
+
+// Reset начинает новый период с now; известные fingerprint сохраняются, давно не встречавшиеся забываются
+func (c *Collector) Reset(now time.Time) {
+	fps := c.stats.Fingerprints
+	for key, fp := range fps {
+		if now.Sub(fp.LastSeen) > fingerprintTTL {
+			delete(fps, key)
+		}
+	}
+	c.stats = Stats{Since: now, Fingerprints: fps}
+	c.stats.init()
+	c.dirty = true
+}
+
+// Save пишет счётчики в файл, если они менялись; атомарно через временный файл
+func (c *Collector) Save() error {
+	if c == nil || !c.dirty {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(c.stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования статистики: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла статистики: %w", err)
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи статистики: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи статистики: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сохранения файла статистики: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// sortedCounts по убыванию, при равенстве по имени; limit 0 - все
+func sortedCounts(m map[string]int, limit int) []Count {
+	out := make([]Count, 0, len(m))
+	for name, n := range m {
+		out = append(out, Count{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
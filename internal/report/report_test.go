@@ -0,0 +1,134 @@
+package report
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// alertEntry запись, как её видит Collector.Alert: разобранная строка с ключом дедупликации без group_by
+func alertEntry(t *testing.T, line, rule string) log_processing.LogEntry {
+	t.Helper()
+	entry, err := parser.ParseLine(line)
+	if err != nil {
+		t.Fatalf("ошибка разбора строки: %v", err)
+	}
+	entry.Rule = rule
+	entry.Fingerprint = protect_from_duplicates.Key(entry, nil)
+	return entry
+}
+
+func TestCollector_CountsPersistAndReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report_stats.json")
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	c, err := Open(path, start)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	c.Lines("app.log", 5)
+	c.ParseFailure("app.log")
+	for _, msg := range []string{"order 1 failed", "order 2 failed", "db timeout"} {
+		c.Parsed(log_processing.LogEntry{Level: "ERROR", Message: msg})
+	}
+	c.Parsed(log_processing.LogEntry{Level: "info", Message: "ok"})
+	first := alertEntry(t, "2026-03-01T09:01:00Z [ERROR] order 1 failed", "orders")
+	c.Alert(first, start.Add(time.Minute))
+	c.Alert(alertEntry(t, "2026-03-01T09:02:00Z [ERROR] order 2 failed", "orders"), start.Add(2*time.Minute))
+	c.Delivery("tg", 2, 0)
+	c.Delivery("tg", 3, 1)
+	c.Delivery("tg", 1, 0) // очередь пересоздана после reload, счёт с нуля
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("ошибка сохранения: %v", err)
+	}
+	c, err = Open(path, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ошибка повторного открытия: %v", err)
+	}
+
+	r := c.Build(start.Add(24 * time.Hour))
+	if !r.From.Equal(start) {
+		t.Fatalf("период должен пережить перезапуск, начало %v", r.From)
+	}
+	if len(r.Sources) != 1 || r.Sources[0].Lines != 5 || r.Sources[0].ParseFailures != 1 {
+		t.Fatalf("неожиданные счётчики источника: %+v", r.Sources)
+	}
+	if len(r.Levels) != 2 || r.Levels[0] != (Count{"ERROR", 3}) || r.Levels[1] != (Count{"INFO", 1}) {
+		t.Fatalf("неожиданные счётчики уровней: %+v", r.Levels)
+	}
+	if r.TopErrors[0] != (Count{"order <n> failed", 2}) {
+		t.Fatalf("ошибки должны группироваться по нормализованному сообщению: %+v", r.TopErrors)
+	}
+	if len(r.TopRules) != 1 || r.TopRules[0] != (Count{"orders", 2}) {
+		t.Fatalf("неожиданные правила: %+v", r.TopRules)
+	}
+	if r.NewTotal != 1 || r.NewFingerprints[0].Key != protect_from_duplicates.StableKey(first) || r.NewFingerprints[0].Message != "order <n> failed" {
+		t.Fatalf("повторы с другим временем и номером - один новый алерт, получено %+v", r.NewFingerprints)
+	}
+	if len(r.Delivery) != 1 || r.Delivery[0].Failed != 4 || r.Delivery[0].Dropped != 1 {
+		t.Fatalf("неожиданные ошибки доставки: %+v", r.Delivery)
+	}
+
+	next := start.Add(24 * time.Hour)
+	c.Reset(next)
+	c.Alert(alertEntry(t, "2026-03-02T09:01:00Z [ERROR] order 3 failed", "orders"), next.Add(time.Minute))
+	r = c.Build(next.Add(24 * time.Hour))
+	if r.NewTotal != 0 || len(r.Sources) != 0 || r.TopRules[0].Count != 1 {
+		t.Fatalf("после Reset счётчики начинаются заново, а fingerprint уже известен: %+v", r)
+	}
+}
+
+func TestCollector_FingerprintsCapped(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	c, err := Open(filepath.Join(t.TempDir(), "report_stats.json"), start)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	oldest := alertEntry(t, "2026-03-01T09:00:00Z [ERROR] order 1 failed", "orders")
+	c.Alert(oldest, start)
+	for i := 1; i <= maxFingerprints; i++ {
+		c.Alert(log_processing.LogEntry{Rule: fmt.Sprintf("rule-%d", i), Message: "failed"}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	if n := len(c.stats.Fingerprints); n != maxFingerprints {
+		t.Fatalf("ожидается не больше %d известных алертов, получено %d", maxFingerprints, n)
+	}
+	if _, ok := c.stats.Fingerprints[protect_from_duplicates.StableKey(oldest)]; ok {
+		t.Fatal("должен вытесняться алерт, который дольше всех не встречался")
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	daily, err := NewSchedule("daily", "09:00", "", "UTC")
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC) // воскресенье
+	if got := daily.Next(at.Add(-time.Minute)); !got.Equal(at) {
+		t.Fatalf("ожидается %v, получено %v", at, got)
+	}
+	if got := daily.Next(at); !got.Equal(at.AddDate(0, 0, 1)) {
+		t.Fatalf("время отчёта не должно повторяться, получено %v", got)
+	}
+
+	weekly, err := NewSchedule("weekly", "18:30", "friday", "UTC")
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	if got := weekly.Next(at); !got.Equal(time.Date(2026, 3, 6, 18, 30, 0, 0, time.UTC)) {
+		t.Fatalf("ожидается пятница 18:30, получено %v", got)
+	}
+
+	for _, bad := range [][2]string{{"hourly", "09:00"}, {"daily", "9am"}} {
+		if _, err := NewSchedule(bad[0], bad[1], "", ""); err == nil {
+			t.Fatalf("ожидается ошибка для %v", bad)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule когда отправлять отчёт: каждый день или раз в неделю в заданное время
+type Schedule struct {
+	weekly  bool
+	weekday time.Weekday
+	hour    int
+	minute  int
+	loc     *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// NewSchedule every - daily | weekly, at - HH:MM, weekday - mon..sun для weekly; timezone IANA, пусто = локальное время
+func NewSchedule(every, at, weekday, timezone string) (Schedule, error) {
+	s := Schedule{loc: time.Local}
+	if timezone != "" {
+		var err error
+		if s.loc, err = time.LoadLocation(timezone); err != nil {
+			return Schedule{}, fmt.Errorf("неизвестный часовой пояс %q: %w", timezone, err)
+		}
+	}
+
+	switch every {
+	case "daily":
+	case "weekly":
+		s.weekly = true
+		name := strings.ToLower(strings.TrimSpace(weekday))
+		if len(name) > 3 {
+			name = name[:3] // monday -> mon
+		}
+		wd, ok := weekdays[name]
+		if !ok {
+			return Schedule{}, fmt.Errorf("неизвестный день недели %q", weekday)
+		}
+		s.weekday = wd
+	default:
+		return Schedule{}, fmt.Errorf("every должен быть daily|weekly")
+	}
+
+	t, err := time.Parse("15:04", strings.TrimSpace(at))
+	if err != nil {
+		return Schedule{}, fmt.Errorf("at: ожидается время в формате HH:MM, получено %q", at)
+	}
+	s.hour, s.minute = t.Hour(), t.Minute()
+	return s, nil
+}
+
+// Next ближайшее время отчёта строго после after
+func (s Schedule) Next(after time.Time) time.Time {
+	t := after.In(s.loc)
+	for i := 0; ; i++ {
+		// time.Date сам переносит день через границу месяца и учитывает переход на летнее время
+		c := time.Date(t.Year(), t.Month(), t.Day()+i, s.hour, s.minute, 0, 0, s.loc)
+		if c.After(after) && (!s.weekly || c.Weekday() == s.weekday) {
+			return c
+		}
+	}
+}
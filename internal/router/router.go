@@ -0,0 +1,88 @@
+package router
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"strings"
+)
+
+type route struct {
+	levels       map[string]bool
+	rules        map[string]bool
+	fields       map[string]string
+	destinations []string
+}
+
+// Router решает, каким получателям отправить алерт.
+// Если маршрутов нет, алерт уходит всем получателям.
+type Router struct {
+	routes   []route
+	all      []string
+	unrouted []string // если ни один маршрут не подошёл
+}
+
+func New(cfg *config.Config) *Router {
+	r := &Router{all: cfg.DestinationNames(), unrouted: cfg.Unrouted}
+	for _, rc := range cfg.Routes {
+		rt := route{
+			levels:       make(map[string]bool),
+			rules:        make(map[string]bool),
+			fields:       rc.Fields,
+			destinations: rc.Destinations,
+		}
+		for _, l := range rc.Levels {
+			rt.levels[strings.ToUpper(l)] = true
+		}
+		for _, name := range rc.Rules {
+			rt.rules[name] = true
+		}
+		r.routes = append(r.routes, rt)
+	}
+	return r
+}
+
+// Route имена получателей для записи, без повторов, в порядке маршрутов.
+// Если ни один маршрут не подошёл - получатели из unrouted, которые могут быть пустыми.
+func (r *Router) Route(entry log_processing.LogEntry) []string {
+	if len(r.routes) == 0 {
+		return r.all
+	}
+
+	var out []string
+	seen := make(map[string]bool)
+	for _, rt := range r.routes {
+		if !rt.matches(entry) {
+			continue
+		}
+		for _, d := range rt.destinations {
+			if !seen[d] {
+				seen[d] = true
+				out = append(out, d)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return r.unrouted
+	}
+	return out
+}
+
+// All все получатели (для служебных сообщений: сводки silences и т.п.)
+func (r *Router) All() []string {
+	return r.all
+}
+
+func (rt route) matches(entry log_processing.LogEntry) bool {
+	if len(rt.levels) > 0 && !rt.levels[strings.ToUpper(entry.Level)] {
+		return false
+	}
+	if len(rt.rules) > 0 && !rt.rules[entry.Rule] {
+		return false
+	}
+	for k, v := range rt.fields {
+		if got, ok := entry.Fields[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
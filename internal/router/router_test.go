@@ -0,0 +1,58 @@
+package router
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"reflect"
+	"testing"
+)
+
+func TestRouter_Route(t *testing.T) {
+	cfg := &config.Config{
+		Destinations: map[string]config.DestinationConfig{
+			"oncall": {Type: "telegram"}, "archive": {Type: "stdout"}, "payments": {Type: "telegram"},
+		},
+		Routes: []config.RouteConfig{
+			{Levels: []string{"ERROR"}, Destinations: []string{"oncall"}},
+			{Destinations: []string{"archive"}},
+			{Rules: []string{"payments"}, Fields: map[string]string{"gateway": "stripe"}, Destinations: []string{"payments", "oncall"}},
+		},
+	}
+	r := New(cfg)
+
+	got := r.Route(log_processing.LogEntry{Level: "ERROR", Rule: "payments", Fields: map[string]string{"gateway": "stripe"}})
+	if want := []string{"oncall", "archive", "payments"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ожидается %v, получено %v", want, got)
+	}
+
+	got = r.Route(log_processing.LogEntry{Level: "INFO", Rule: "payments", Fields: map[string]string{"gateway": "paypal"}})
+	if want := []string{"archive"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ожидается %v, получено %v", want, got)
+	}
+}
+
+func TestRouter_NoRoutes_AllDestinations(t *testing.T) {
+	r := New(&config.Config{Destinations: map[string]config.DestinationConfig{"b": {}, "a": {}}})
+
+	if got := r.Route(log_processing.LogEntry{Level: "DEBUG"}); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("без маршрутов ожидаются все получатели, получено %v", got)
+	}
+}
+
+func TestRouter_Unrouted(t *testing.T) {
+	cfg := &config.Config{
+		Destinations: map[string]config.DestinationConfig{"oncall": {Type: "telegram"}, "console": {Type: "stdout"}},
+		Routes:       []config.RouteConfig{{Levels: []string{"ERROR"}, Destinations: []string{"oncall"}}},
+	}
+	if got := New(cfg).Route(log_processing.LogEntry{Level: "INFO"}); len(got) != 0 {
+		t.Fatalf("без unrouted неподошедший алерт никому не уходит, получено %v", got)
+	}
+
+	cfg.Unrouted = []string{"console"}
+	if got := New(cfg).Route(log_processing.LogEntry{Level: "INFO"}); !reflect.DeepEqual(got, []string{"console"}) {
+		t.Fatalf("ожидается получатель из unrouted, получено %v", got)
+	}
+	if got := New(cfg).Route(log_processing.LogEntry{Level: "ERROR"}); !reflect.DeepEqual(got, []string{"oncall"}) {
+		t.Fatalf("unrouted не должен добавляться к подошедшим маршрутам, получено %v", got)
+	}
+}
@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"sort"
+	"time"
+)
+
+// Held алерты, придержанные окном с action=delay до его окончания
+type Held struct {
+	Window  string
+	Until   time.Time
+	Entries []log_processing.LogEntry
+}
+
+// Holder копит придержанные алерты в памяти до конца окна; при остановке их забирает Drain
+type Holder struct {
+	held map[string]*Held
+}
+
+func NewHolder() *Holder {
+	return &Holder{held: make(map[string]*Held)}
+}
+
+func (h *Holder) Add(d Decision, entry log_processing.LogEntry) {
+	key := d.Window + "\x00" + d.Until.Format(time.RFC3339)
+	hd, ok := h.held[key]
+	if !ok {
+		hd = &Held{Window: d.Window, Until: d.Until}
+		h.held[key] = hd
+	}
+	hd.Entries = append(hd.Entries, entry)
+}
+
+// Due забирает группы, окно которых закончилось к моменту now
+func (h *Holder) Due(now time.Time) []Held {
+	var due []Held
+	for key, hd := range h.held {
+		if now.Before(hd.Until) {
+			continue
+		}
+		due = append(due, *hd)
+		delete(h.held, key)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Until.Before(due[j].Until) })
+	return due
+}
+
+// Drain забирает все придержанные группы, не дожидаясь конца окон (при остановке)
+func (h *Holder) Drain() []Held {
+	return h.Due(time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// Len сколько алертов сейчас придержано
+func (h *Holder) Len() int {
+	n := 0
+	for _, hd := range h.held {
+		n += len(hd.Entries)
+	}
+	return n
+}
@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // чтобы timezone работал и без системной базы часовых поясов
+)
+
+type Action string
+
+const (
+	ActionNone      Action = ""
+	ActionDrop      Action = "drop"      // не отправлять
+	ActionDelay     Action = "delay"     // придержать до конца окна и отправить одной сводкой
+	ActionDowngrade Action = "downgrade" // отправить сразу, но с пониженным уровнем
+)
+
+// Decision результат проверки записи по расписанию
+type Decision struct {
+	Window string
+	Action Action
+	Until  time.Time // конец окна, для delay
+	Level  string    // новый уровень, для downgrade
+}
+
+type window struct {
+	name         string
+	days         map[time.Weekday]bool // пусто = каждый день
+	from, to     int                   // минуты от начала суток; to <= from значит окно через полночь (равные = сутки)
+	levels       map[string]bool       // пусто = все уровни
+	exceptLevels map[string]bool
+	rules        map[string]bool // пусто = все правила
+	action       Action
+	downgradeTo  string
+}
+
+type Scheduler struct {
+	loc     *time.Location
+	windows []window
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func New(cfg config.SchedulesConfig) (*Scheduler, error) {
+	loc := time.Local
+	if cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("неизвестный часовой пояс %q: %w", cfg.Timezone, err)
+		}
+	}
+
+	s := &Scheduler{loc: loc}
+	for _, wc := range cfg.Windows {
+		w := window{
+			name:         wc.Name,
+			days:         make(map[time.Weekday]bool),
+			levels:       toSet(wc.Levels, strings.ToUpper),
+			exceptLevels: toSet(wc.ExceptLevels, strings.ToUpper),
+			rules:        toSet(wc.Rules, nil),
+			action:       Action(strings.ToLower(wc.Action)),
+			downgradeTo:  strings.ToUpper(wc.DowngradeTo),
+		}
+
+		for _, l := range slices.Concat(wc.Levels, wc.ExceptLevels, []string{wc.DowngradeTo}) {
+			if l = strings.ToUpper(strings.TrimSpace(l)); l != "" && !slices.Contains(parser.Levels, l) {
+				return nil, fmt.Errorf("окно %q: неизвестный уровень %q, ожидается %s", wc.Name, l, strings.Join(parser.Levels, "|"))
+			}
+		}
+
+		for _, d := range wc.Days {
+			name := strings.ToLower(strings.TrimSpace(d))
+			if len(name) > 3 {
+				name = name[:3] // monday -> mon
+			}
+			wd, ok := weekdays[name]
+			if !ok {
+				return nil, fmt.Errorf("окно %q: неизвестный день недели %q", wc.Name, d)
+			}
+			w.days[wd] = true
+		}
+
+		var err error
+		if w.from, err = parseClock(wc.From); err != nil {
+			return nil, fmt.Errorf("окно %q: from: %w", wc.Name, err)
+		}
+		if w.to, err = parseClock(wc.To); err != nil {
+			return nil, fmt.Errorf("окно %q: to: %w", wc.Name, err)
+		}
+
+		switch w.action {
+		case ActionDrop, ActionDelay:
+		case ActionDowngrade:
+			if w.downgradeTo == "" {
+				w.downgradeTo = "INFO"
+			}
+		default:
+			return nil, fmt.Errorf("окно %q: action должен быть drop|delay|downgrade", wc.Name)
+		}
+
+		s.windows = append(s.windows, w)
+	}
+
+	return s, nil
+}
+
+// Evaluate возвращает действие первого окна (в порядке конфига), которое активно в момент now
+// и под которое подходит запись. Если таких нет, Action = ActionNone.
+func (s *Scheduler) Evaluate(entry log_processing.LogEntry, now time.Time) Decision {
+	now = now.In(s.loc)
+	for _, w := range s.windows {
+		if !w.appliesTo(entry) {
+			continue
+		}
+		until, ok := w.activeUntil(now)
+		if !ok {
+			continue
+		}
+		d := Decision{Window: w.name, Action: w.action, Until: until}
+		if w.action == ActionDowngrade {
+			d.Level = w.downgradeTo
+		}
+		return d
+	}
+	return Decision{}
+}
+
+func (w window) appliesTo(entry log_processing.LogEntry) bool {
+	level := strings.ToUpper(entry.Level)
+	if len(w.levels) > 0 && !w.levels[level] {
+		return false
+	}
+	if w.exceptLevels[level] {
+		return false
+	}
+	if len(w.rules) > 0 && !w.rules[entry.Rule] {
+		return false
+	}
+	return true
+}
+
+// activeUntil проверяет, попадает ли now в окно, и возвращает момент окончания окна
+func (w window) activeUntil(now time.Time) (time.Time, bool) {
+	minute := now.Hour()*60 + now.Minute()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// через time.Date, а не midnight+минуты: в день перевода часов в сутках 23 или 25 часов
+	at := func(day time.Time, m int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), m/60, m%60, 0, 0, day.Location())
+	}
+
+	if w.from < w.to {
+		if w.dayAllowed(now.Weekday()) && minute >= w.from && minute < w.to {
+			return at(midnight, w.to), true
+		}
+		return time.Time{}, false
+	}
+
+	// Окно через полночь: день недели относится к дню начала окна
+	if minute >= w.from && w.dayAllowed(now.Weekday()) {
+		return at(midnight.AddDate(0, 0, 1), w.to), true
+	}
+	if minute < w.to && w.dayAllowed(midnight.AddDate(0, 0, -1).Weekday()) {
+		return at(midnight, w.to), true
+	}
+	return time.Time{}, false
+}
+
+func (w window) dayAllowed(d time.Weekday) bool {
+	return len(w.days) == 0 || w.days[d]
+}
+
+// parseClock разбирает время вида "HH:MM" в минуты от начала суток
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(v))
+	if err != nil {
+		return 0, fmt.Errorf("ожидается время в формате HH:MM, получено %q", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func toSet(values []string, norm func(string) string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if norm != nil {
+			v = norm(v)
+		}
+		if v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
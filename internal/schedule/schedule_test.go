@@ -0,0 +1,143 @@
+package schedule
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"testing"
+	"time"
+)
+
+func newTestScheduler(t *testing.T) *Scheduler {
+	t.Helper()
+	s, err := New(config.SchedulesConfig{
+		Timezone: "Europe/Moscow",
+		Windows: []config.ScheduleWindow{
+			{Name: "maintenance", Days: []string{"sunday"}, From: "02:00", To: "06:00", ExceptLevels: []string{"ERROR"}, Action: "delay"},
+			{Name: "quiet", From: "23:00", To: "08:00", Levels: []string{"INFO", "DEBUG"}, Action: "drop"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	return s
+}
+
+func TestScheduler_QuietHoursAcrossMidnight(t *testing.T) {
+	s := newTestScheduler(t)
+	msk, _ := time.LoadLocation("Europe/Moscow")
+
+	// Среда 03:00 по Москве: INFO отбрасывается, ERROR проходит
+	night := time.Date(2026, 2, 25, 3, 0, 0, 0, msk)
+	if d := s.Evaluate(log_processing.LogEntry{Level: "INFO"}, night); d.Action != ActionDrop || d.Window != "quiet" {
+		t.Fatalf("ожидается drop окном quiet, получено %+v", d)
+	}
+	if d := s.Evaluate(log_processing.LogEntry{Level: "ERROR"}, night); d.Action != ActionNone {
+		t.Fatalf("ERROR не должен задерживаться ночью, получено %+v", d)
+	}
+
+	// Время переводится в часовой пояс расписания: 20:30 UTC = 23:30 MSK
+	if d := s.Evaluate(log_processing.LogEntry{Level: "DEBUG"}, time.Date(2026, 2, 25, 20, 30, 0, 0, time.UTC)); d.Action != ActionDrop {
+		t.Fatalf("ожидается drop для 23:30 MSK, получено %+v", d)
+	}
+
+	if d := s.Evaluate(log_processing.LogEntry{Level: "INFO"}, time.Date(2026, 2, 25, 12, 0, 0, 0, msk)); d.Action != ActionNone {
+		t.Fatalf("днём окно не должно действовать, получено %+v", d)
+	}
+}
+
+func TestScheduler_MaintenanceDelayUntilEnd(t *testing.T) {
+	s := newTestScheduler(t)
+	msk, _ := time.LoadLocation("Europe/Moscow")
+
+	sunday := time.Date(2026, 3, 1, 4, 0, 0, 0, msk)
+	d := s.Evaluate(log_processing.LogEntry{Level: "INFO"}, sunday)
+	if d.Action != ActionDelay || !d.Until.Equal(time.Date(2026, 3, 1, 6, 0, 0, 0, msk)) {
+		t.Fatalf("ожидается delay до 06:00, получено %+v", d)
+	}
+
+	if d := s.Evaluate(log_processing.LogEntry{Level: "ERROR"}, sunday); d.Action != ActionNone {
+		t.Fatalf("ERROR не должен задерживаться, получено %+v", d)
+	}
+
+	h := NewHolder()
+	h.Add(d, log_processing.LogEntry{Level: "INFO"})
+	if due := h.Due(sunday); len(due) != 0 {
+		t.Fatal("до конца окна ничего не должно отправляться")
+	}
+	if due := h.Due(d.Until); len(due) != 1 || len(due[0].Entries) != 1 {
+		t.Fatalf("после окончания окна ожидается одна сводка, получено %+v", due)
+	}
+}
+
+func TestNew_InvalidAction(t *testing.T) {
+	_, err := New(config.SchedulesConfig{Windows: []config.ScheduleWindow{{Name: "x", From: "01:00", To: "02:00", Action: "pause"}}})
+	if err == nil {
+		t.Fatal("ожидается ошибка для неизвестного action")
+	}
+}
+
+func TestScheduler_DSTChangeDay(t *testing.T) {
+	s, err := New(config.SchedulesConfig{
+		Timezone: "Europe/Berlin",
+		Windows:  []config.ScheduleWindow{{Name: "night", From: "01:00", To: "05:00", Action: "delay"}},
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	// 29 марта 2026 в 02:00 часы переводятся на 03:00, в сутках 23 часа
+	d := s.Evaluate(log_processing.LogEntry{Level: "INFO"}, time.Date(2026, 3, 29, 1, 30, 0, 0, berlin))
+	if want := time.Date(2026, 3, 29, 5, 0, 0, 0, berlin); !d.Until.Equal(want) {
+		t.Fatalf("окно должно заканчиваться в 05:00 по местному времени, получено %v", d.Until.In(berlin))
+	}
+}
+
+func TestNew_UnknownLevel(t *testing.T) {
+	_, err := New(config.SchedulesConfig{Windows: []config.ScheduleWindow{
+		{Name: "maintenance", From: "02:00", To: "06:00", ExceptLevels: []string{"CRITICAL"}, Action: "delay"},
+	}})
+	if err == nil {
+		t.Fatal("ожидается ошибка: парсер не знает уровня CRITICAL, исключение никогда бы не сработало")
+	}
+}
+
+func TestScheduler_ExceptFatal(t *testing.T) {
+	s, err := New(config.SchedulesConfig{
+		Timezone: "UTC",
+		Windows:  []config.ScheduleWindow{{Name: "maintenance", From: "02:00", To: "06:00", ExceptLevels: []string{"FATAL"}, Action: "delay"}},
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	for _, line := range []string{"2026-03-01T03:00:00Z [ERROR] db timeout", "2026-03-01T03:00:00Z [WARN] slow query"} {
+		entry, err := parser.ParseLine(line)
+		if err != nil {
+			t.Fatalf("ошибка разбора строки: %v", err)
+		}
+		if d := s.Evaluate(entry, now); d.Action != ActionDelay {
+			t.Fatalf("всё, кроме FATAL, должно придерживаться, получено %+v для %q", d, line)
+		}
+	}
+
+	fatal, err := parser.ParseLine("2026-03-01T03:00:00Z [FATAL] out of memory")
+	if err != nil {
+		t.Fatalf("ошибка разбора строки: %v", err)
+	}
+	if d := s.Evaluate(fatal, now); d.Action != ActionNone {
+		t.Fatalf("FATAL не должен задерживаться, получено %+v", d)
+	}
+}
+
+func TestHolder_Drain(t *testing.T) {
+	h := NewHolder()
+	until := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	h.Add(Decision{Window: "maintenance", Action: ActionDelay, Until: until}, log_processing.LogEntry{Level: "INFO"})
+
+	if due := h.Drain(); len(due) != 1 || h.Len() != 0 {
+		t.Fatalf("Drain должен забрать всё до конца окна, получено %+v, осталось %d", due, h.Len())
+	}
+}
@@ -0,0 +1,165 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiscordSender вебхук Discord. Тело запроса - JSON с embed, который подготовил FormatDiscord;
+// username и avatar_url из конфига добавляются перед отправкой.
+// Учитывает лимиты Discord: если X-RateLimit-Remaining дошёл до 0, следующий запрос ждёт X-RateLimit-Reset-After.
+type DiscordSender struct {
+	url       string
+	overrides map[string]string
+	client    *http.Client
+
+	mu      sync.Mutex
+	resetAt time.Time // до этого момента лимит запросов исчерпан
+}
+
+// discordRateLimitResponse тело ответа 429
+type discordRateLimitResponse struct {
+	Message    string  `json:"message"`
+	RetryAfter float64 `json:"retry_after"` // секунды, может быть дробным
+	Global     bool    `json:"global"`
+}
+
+func NewDiscordSender(cfg config.DiscordConfig) (*DiscordSender, error) {
+	if cfg.WebhookURL == "" {
+		return nil, fmt.Errorf("не задан webhook_url для discord")
+	}
+
+	u, err := url.Parse(cfg.WebhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный webhook_url для discord: %w", err)
+	}
+	if cfg.ThreadID != "" {
+		q := u.Query()
+		q.Set("thread_id", cfg.ThreadID)
+		u.RawQuery = q.Encode()
+	}
+
+	overrides := make(map[string]string)
+	if cfg.Username != "" {
+		overrides["username"] = cfg.Username
+	}
+	if cfg.AvatarURL != "" {
+		overrides["avatar_url"] = cfg.AvatarURL
+	}
+
+	return &DiscordSender{
+		url:       u.String(),
+		overrides: overrides,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *DiscordSender) Send(ctx context.Context, msg Message) error {
+	payload := make(map[string]any)
+	if err := json.Unmarshal([]byte(msg.Text), &payload); err != nil {
+		// не JSON: отправляем как простой текст
+		payload = map[string]any{"content": msg.Text}
+	}
+	for k, v := range s.overrides {
+		payload[k] = v
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования в формат JSON: %w", err)
+	}
+
+	if err := s.waitRateLimit(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса discord: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось выполнить запрос в discord: %w", err)
+	}
+	defer resp.Body.Close()
+
+	s.updateRateLimit(resp.Header)
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode:  resp.StatusCode,
+		Description: strings.TrimSpace(string(body)),
+		RetryAfter:  parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var rl discordRateLimitResponse
+		if json.Unmarshal(body, &rl) == nil {
+			apiErr.Description = rl.Message
+			if d := secondsToDuration(rl.RetryAfter); d > 0 {
+				apiErr.RetryAfter = d
+			}
+		}
+		s.mu.Lock()
+		if until := time.Now().Add(apiErr.RetryAfter); until.After(s.resetAt) {
+			s.resetAt = until
+		}
+		s.mu.Unlock()
+	}
+	return fmt.Errorf("ошибка api discord: %w", apiErr)
+}
+
+// waitRateLimit ждёт сброса лимита, если предыдущий ответ сообщил, что запросов не осталось
+func (s *DiscordSender) waitRateLimit(ctx context.Context) error {
+	s.mu.Lock()
+	wait := time.Until(s.resetAt)
+	s.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// updateRateLimit запоминает момент сброса лимита по заголовкам X-RateLimit-*
+func (s *DiscordSender) updateRateLimit(h http.Header) {
+	if strings.TrimSpace(h.Get("X-RateLimit-Remaining")) != "0" {
+		return
+	}
+	reset, err := strconv.ParseFloat(strings.TrimSpace(h.Get("X-RateLimit-Reset-After")), 64)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.resetAt = time.Now().Add(secondsToDuration(reset))
+	s.mu.Unlock()
+}
+
+func secondsToDuration(sec float64) time.Duration {
+	if sec <= 0 {
+		return 0
+	}
+	return time.Duration(sec * float64(time.Second))
+}
@@ -0,0 +1,69 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDiscordSender_OverridesAndRateLimit(t *testing.T) {
+	var calls []time.Time
+	var gotBody map[string]any
+	var gotThread string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, time.Now())
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &gotBody)
+		gotThread = r.URL.Query().Get("thread_id")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", "0.2")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ds, err := NewDiscordSender(config.DiscordConfig{WebhookURL: srv.URL, Username: "bugbot", ThreadID: "42"})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := ds.Send(context.Background(), Message{Text: `{"embeds":[{"title":"ERROR: boom"}]}`}); err != nil {
+			t.Fatalf("ожидается успешная отправка, получено: %v", err)
+		}
+	}
+
+	if gotBody["username"] != "bugbot" || gotBody["embeds"] == nil {
+		t.Fatalf("неверное тело: %v", gotBody)
+	}
+	if gotThread != "42" {
+		t.Fatalf("ожидается thread_id=42, получено %q", gotThread)
+	}
+	if d := calls[1].Sub(calls[0]); d < 150*time.Millisecond {
+		t.Fatalf("второй запрос должен ждать сброса лимита, прошло %s", d)
+	}
+}
+
+func TestDiscordSender_TooManyRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message":"You are being rate limited.","retry_after":1.5,"global":false}`))
+	}))
+	defer srv.Close()
+
+	ds, _ := NewDiscordSender(config.DiscordConfig{WebhookURL: srv.URL})
+	err := ds.Send(context.Background(), Message{Text: "plain text"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("ожидается APIError, получено: %v", err)
+	}
+	if !IsRetryable(err) || apiErr.RetryAfter != 1500*time.Millisecond {
+		t.Fatalf("ожидается временная ошибка с retry_after=1.5s, получено %+v", apiErr)
+	}
+}
@@ -0,0 +1,90 @@
+package sender
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"time"
+)
+
+// APIError ответ внешнего API с кодом ошибки
+type APIError struct {
+	StatusCode  int
+	Description string
+	RetryAfter  time.Duration // из parameters.retry_after (Telegram) или заголовка Retry-After
+}
+
+func (e *APIError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("ошибка api: статус=%d описание=%s повторить через=%s", e.StatusCode, e.Description, e.RetryAfter)
+	}
+	return fmt.Sprintf("ошибка api: статус=%d описание=%s", e.StatusCode, e.Description)
+}
+
+// Temporary 429 и 5xx имеет смысл повторить, остальные 4xx (например 400 "chat not found") - нет
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// IsRetryable можно ли повторить отправку после ошибки err.
+// Повторяем только временные ошибки: сетевые, 429 и 5xx.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	// SMTP: 4xx - временный отказ сервера, 5xx - постоянный
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500
+	}
+
+	// TLS: недоверенный или просроченный сертификат, чужое имя хоста - повтор не поможет.
+	// Проверяем до net.Error: *url.Error с такой причиной тоже реализует net.Error.
+	if isTLSConfigError(err) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// ошибки отправителей, которые сами знают, временные ли они (например, ExecError)
+	var tmp interface{ Temporary() bool }
+	if errors.As(err, &tmp) {
+		return tmp.Temporary()
+	}
+
+	// Таймаут отдельной попытки (но не отмена всей отправки) тоже временная ошибка
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+func isTLSConfigError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var header tls.RecordHeaderError // на TLS-адресе отвечает обычный HTTP
+	return errors.As(err, &verifyErr) || errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &header)
+}
+
+// retryAfter сколько API просит подождать перед повтором
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
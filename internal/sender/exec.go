@@ -0,0 +1,169 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	defaultExecTimeout = 30 * time.Second
+	execStderrLimit    = 4096 // сколько последних байт stderr попадает в лог и в ошибку
+)
+
+// ExecSender запускает локальную команду на каждый алерт: JSON алерта на stdin,
+// основные поля - в переменных окружения ALERT_*. Ненулевой код выхода - ошибка отправки.
+type ExecSender struct {
+	command []string
+	dir     string
+	env     []string
+	timeout time.Duration
+}
+
+// ExecError команда завершилась с ошибкой или не уложилась в таймаут; отправку можно повторить
+type ExecError struct {
+	ExitCode int
+	TimedOut bool
+	Stderr   string
+}
+
+func (e *ExecError) Error() string {
+	if e.TimedOut {
+		return fmt.Sprintf("команда не завершилась за отведённое время, stderr: %s", e.Stderr)
+	}
+	return fmt.Sprintf("команда завершилась с кодом %d, stderr: %s", e.ExitCode, e.Stderr)
+}
+
+func (e *ExecError) Temporary() bool { return true }
+
+func NewExecSender(cfg config.ExecConfig) (*ExecSender, error) {
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return nil, fmt.Errorf("не задана команда exec")
+	}
+
+	timeout := time.Duration(cfg.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultExecTimeout
+	}
+
+	env := make([]string, 0, len(cfg.Env))
+	for k, v := range cfg.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	return &ExecSender{
+		command: cfg.Command,
+		dir:     cfg.Dir,
+		env:     env,
+		timeout: timeout,
+	}, nil
+}
+
+func (s *ExecSender) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(newAlertRecord(msg, time.Now()))
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования алерта для команды: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Dir = s.dir
+	cmd.Env = append(append(os.Environ(), s.env...), alertEnv(msg)...)
+	cmd.Stdin = bytes.NewReader(payload)
+	stderr := &tailBuffer{limit: execStderrLimit}
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	// внуки, унаследовавшие stderr, не должны держать Wait бесконечно
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+	errText := strings.TrimSpace(stderr.String())
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		log.Printf("Команда %s выполнена за %s", s.command[0], elapsed)
+		if errText != "" {
+			log.Printf("stderr команды %s: %s", s.command[0], errText)
+		}
+		return nil
+	case ctx.Err() == context.DeadlineExceeded:
+		log.Printf("Команда %s не завершилась за %s, группа процессов остановлена", s.command[0], s.timeout)
+		return &ExecError{ExitCode: -1, TimedOut: true, Stderr: errText}
+	case errors.As(err, &exitErr):
+		log.Printf("Команда %s завершилась с кодом %d за %s, stderr: %s", s.command[0], exitErr.ExitCode(), elapsed, errText)
+		return &ExecError{ExitCode: exitErr.ExitCode(), Stderr: errText}
+	default:
+		// не удалось запустить (нет файла, нет прав) - повтор не поможет; %v, чтобы syscall.Errno не считался сетевой ошибкой
+		return fmt.Errorf("ошибка запуска команды %s: %v", s.command[0], err)
+	}
+}
+
+// alertEnv поля алерта в переменных окружения; поля из именованных групп - ALERT_FIELD_<ИМЯ>
+func alertEnv(msg Message) []string {
+	env := []string{"ALERT_KIND=" + newAlertRecord(msg, time.Now()).Kind, "ALERT_TEXT=" + msg.Text}
+	if e := msg.Entry; e != nil {
+		env = append(env,
+			"ALERT_TIMESTAMP="+e.Timestamp.Format(time.RFC3339),
+			"ALERT_LEVEL="+e.Level,
+			"ALERT_RULE="+e.Rule,
+			"ALERT_FINGERPRINT="+e.Fingerprint,
+			"ALERT_SOURCE="+e.Source,
+			"ALERT_MESSAGE="+e.Message,
+			"ALERT_RAW="+e.Raw,
+		)
+		for _, name := range sortedKeys(e.Fields) {
+			env = append(env, "ALERT_FIELD_"+envName(name)+"="+e.Fields[name])
+		}
+	}
+	return env
+}
+
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, s)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tailBuffer хранит только последние limit байт вывода
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.limit; over > 0 {
+		b.buf = b.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string { return string(b.buf) }
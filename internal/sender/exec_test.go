@@ -0,0 +1,99 @@
+//go:build unix
+
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExecSender_StdinAndEnv(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	ex, err := NewExecSender(config.ExecConfig{
+		Command: []string{"/bin/sh", "-c", `cat > "$OUT.json"; echo "$ALERT_LEVEL|$ALERT_RULE|$ALERT_FIELD_ORDER_ID|$EXTRA" > "$OUT.env"`},
+		Env:     map[string]string{"OUT": out, "EXTRA": "x"},
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	entry := incidentEntry(t, "2026-03-01T10:00:00Z [ERROR] db timeout")
+	entry.Fields = map[string]string{"order-id": "123"}
+	if err := ex.Send(context.Background(), Message{Text: "text", Entry: entry}); err != nil {
+		t.Fatalf("ожидается успешный запуск, получено: %v", err)
+	}
+
+	var rec alertRecord
+	b, _ := os.ReadFile(out + ".json")
+	if err := json.Unmarshal(b, &rec); err != nil || rec.Fingerprint != entry.Fingerprint || rec.Text != "text" {
+		t.Fatalf("неверный JSON на stdin: %v %s", err, b)
+	}
+	env, _ := os.ReadFile(out + ".env")
+	if got := strings.TrimSpace(string(env)); got != "ERROR|database|123|x" {
+		t.Fatalf("неверные переменные окружения: %q", got)
+	}
+}
+
+func TestExecSender_NonZeroExitIsRetryable(t *testing.T) {
+	ex, _ := NewExecSender(config.ExecConfig{Command: []string{"/bin/sh", "-c", "echo boom >&2; exit 3"}})
+
+	err := ex.Send(context.Background(), Message{Text: "x"})
+	var execErr *ExecError
+	if !errors.As(err, &execErr) || execErr.ExitCode != 3 || execErr.Stderr != "boom" {
+		t.Fatalf("ожидается ExecError с кодом 3 и stderr, получено: %v", err)
+	}
+	if !IsRetryable(err) {
+		t.Fatal("ненулевой код выхода должен повторяться")
+	}
+}
+
+func TestExecSender_MissingCommandIsPermanent(t *testing.T) {
+	ex, _ := NewExecSender(config.ExecConfig{Command: []string{"/nonexistent/remediate"}})
+
+	err := ex.Send(context.Background(), Message{Text: "x"})
+	if err == nil || IsRetryable(err) {
+		t.Fatalf("ошибка запуска не должна повторяться, получено: %v", err)
+	}
+}
+
+func TestExecSender_TimeoutKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	ex, _ := NewExecSender(config.ExecConfig{
+		Command:   []string{"/bin/sh", "-c", `sleep 30 & echo $! > "$PID_FILE"; wait`},
+		Env:       map[string]string{"PID_FILE": pidFile},
+		TimeoutMS: 200,
+	})
+
+	start := time.Now()
+	err := ex.Send(context.Background(), Message{Text: "x"})
+	var execErr *ExecError
+	if !errors.As(err, &execErr) || !execErr.TimedOut {
+		t.Fatalf("ожидается таймаут, получено: %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("команда должна быть остановлена по таймауту")
+	}
+
+	b, _ := os.ReadFile(pidFile)
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	if pid == 0 {
+		t.Fatal("не удалось прочитать pid дочернего процесса")
+	}
+	// дочерний sleep должен быть убит вместе с группой
+	deadline := time.Now().Add(2 * time.Second)
+	for syscall.Kill(pid, 0) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("дочерний процесс %d пережил таймаут", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package sender
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup запускает команду в своей группе процессов и по таймауту убивает всю группу,
+// чтобы дочерние процессы скрипта не оставались висеть
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
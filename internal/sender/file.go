@@ -0,0 +1,214 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSender архив алертов: одна JSON-строка на сообщение, ротация по размеру и времени.
+// Файл открывается на каждую запись, поэтому смена отправителя при перезагрузке конфига ничего не теряет.
+type FileSender struct {
+	path     string
+	maxSize  int64
+	rotate   string
+	maxFiles int
+	maxAge   time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// alertRecord алерт в виде JSON: строка архива (file) и stdin команды (exec)
+type alertRecord struct {
+	ArchivedAt  time.Time         `json:"archived_at"`
+	Timestamp   *time.Time        `json:"timestamp,omitempty"` // время строки лога
+	Kind        string            `json:"kind"`                // alert | resolve | delivery | notice
+	Rule        string            `json:"rule,omitempty"`
+	Level       string            `json:"level,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	Raw         string            `json:"raw,omitempty"`
+	Text        string            `json:"text,omitempty"`      // отформатированный текст
+	RoutedTo    []string          `json:"routed_to,omitempty"` // кому алерт направлен маршрутами; итог доставки каждому - отдельной записью delivery
+
+	// kind=delivery: итог доставки алерта получателю destination
+	Destination string `json:"destination,omitempty"`
+	Status      string `json:"status,omitempty"` // sent | dropped
+	Error       string `json:"error,omitempty"`
+}
+
+func newAlertRecord(msg Message, now time.Time) alertRecord {
+	rec := alertRecord{
+		ArchivedAt: now,
+		Kind:       "notice",
+		Text:       msg.Text,
+		RoutedTo:   msg.Destinations,
+	}
+	if e := msg.Entry; e != nil {
+		rec.Kind = "alert"
+		switch msg.Event {
+		case EventResolve:
+			rec.Kind = "resolve"
+		case EventDelivery:
+			rec.Kind = "delivery"
+		}
+		ts := e.Timestamp
+		rec.Timestamp = &ts
+		rec.Rule = e.Rule
+		rec.Level = e.Level
+		rec.Fingerprint = e.Fingerprint
+		rec.Source = e.Source
+		rec.Message = e.Message
+		rec.Fields = e.Fields
+		rec.Raw = e.Raw
+	}
+	if d := msg.Delivery; d != nil {
+		rec.Destination = d.Destination
+		rec.Status = d.Status
+		rec.Error = d.Error
+	}
+	return rec
+}
+
+func NewFileSender(cfg config.FileConfig) *FileSender {
+	s := &FileSender{
+		path:     cfg.Path,
+		maxSize:  int64(cfg.MaxSizeMB) << 20,
+		rotate:   cfg.Rotate,
+		maxFiles: cfg.MaxFiles,
+		maxAge:   time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+		now:      time.Now,
+	}
+	if s.path == "" {
+		s.path = "alerts.jsonl"
+	}
+	return s
+}
+
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	rec := newAlertRecord(msg, s.now())
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования записи архива: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.rotateIfNeeded(int64(len(line))); err != nil {
+		// архив важнее ротации: пишем в текущий файл
+		log.Printf("Ошибка ротации архива %s: %v", s.path, err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("ошибка записи в архив: %w", err)
+	}
+	return f.Close()
+}
+
+// rotateIfNeeded переименовывает текущий файл в архивный (alerts-20260102-150405.jsonl),
+// если следующая запись превысит max_size_mb или файл начат в прошлом периоде rotate
+func (s *FileSender) rotateIfNeeded(next int64) error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	bySize := s.maxSize > 0 && info.Size()+next > s.maxSize
+	byTime := s.rotate != "" && periodKey(s.rotate, info.ModTime()) != periodKey(s.rotate, s.now())
+	if !bySize && !byTime {
+		return nil
+	}
+
+	ext := filepath.Ext(s.path)
+	base := strings.TrimSuffix(s.path, ext)
+	target := base + "-" + info.ModTime().Format("20060102-150405") + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(target); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		target = base + "-" + info.ModTime().Format("20060102-150405") + "." + strconv.Itoa(i) + ext
+	}
+	if err := os.Rename(s.path, target); err != nil {
+		return err
+	}
+
+	s.cleanup(base, ext)
+	return nil
+}
+
+// cleanup удаляет архивные файлы сверх max_files и старше max_age_days
+func (s *FileSender) cleanup(base, ext string) {
+	if s.maxFiles <= 0 && s.maxAge <= 0 {
+		return
+	}
+	// только свои архивы: "alerts.a-*.jsonl" совпал бы и с архивом получателя a-b (alerts.a-b.jsonl)
+	own := regexp.MustCompile(`^` + regexp.QuoteMeta(filepath.Base(base)) + `-\d{8}-\d{6}(\.\d+)?` + regexp.QuoteMeta(ext) + `$`)
+	entries, err := os.ReadDir(filepath.Dir(base))
+	if err != nil {
+		return
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && own.MatchString(e.Name()) {
+			files = append(files, filepath.Join(filepath.Dir(base), e.Name()))
+		}
+	}
+	// имена содержат время, поэтому сортировка по имени = по времени
+	sort.Strings(files)
+
+	for i, name := range files {
+		expired := s.maxFiles > 0 && i < len(files)-s.maxFiles
+		if !expired && s.maxAge > 0 {
+			if info, err := os.Stat(name); err == nil && s.now().Sub(info.ModTime()) > s.maxAge {
+				expired = true
+			}
+		}
+		if expired {
+			if err := os.Remove(name); err != nil {
+				log.Printf("Ошибка удаления старого архива %s: %v", name, err)
+			}
+		}
+	}
+}
+
+// periodKey период, к которому относится момент t: при смене периода архив ротируется
+func periodKey(rotate string, t time.Time) string {
+	switch rotate {
+	case "hourly":
+		return t.Format("2006010215")
+	case "daily":
+		return t.Format("20060102")
+	case "weekly":
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%d-%02d", y, w)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,168 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readRecords(t *testing.T, path string) []alertRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("ошибка открытия архива: %v", err)
+	}
+	defer f.Close()
+
+	var out []alertRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r alertRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("строка архива должна быть JSON: %v", err)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func TestFileSender_WritesRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	fs := NewFileSender(config.FileConfig{Path: path})
+
+	entry := incidentEntry(t, "2026-03-01T10:00:00Z [ERROR] db timeout")
+	entry.Raw = "raw line"
+	ctx := context.Background()
+	if err := fs.Send(ctx, Message{Text: "text", Entry: entry, Destinations: []string{"oncall", "archive"}}); err != nil {
+		t.Fatalf("ошибка записи: %v", err)
+	}
+	if err := fs.Send(ctx, Message{Text: "сводка"}); err != nil {
+		t.Fatalf("ошибка записи: %v", err)
+	}
+
+	recs := readRecords(t, path)
+	if len(recs) != 2 {
+		t.Fatalf("ожидается 2 записи, получено %d", len(recs))
+	}
+	a := recs[0]
+	if a.Kind != "alert" || a.Rule != "database" || a.Fingerprint != entry.Fingerprint || a.Raw != "raw line" || a.Timestamp == nil {
+		t.Fatalf("неверная запись алерта: %+v", a)
+	}
+	if strings.Join(a.RoutedTo, ",") != "oncall,archive" {
+		t.Fatalf("неверные получатели: %v", a.RoutedTo)
+	}
+	if recs[1].Kind != "notice" || recs[1].Text != "сводка" {
+		t.Fatalf("неверная служебная запись: %+v", recs[1])
+	}
+}
+
+func TestFileSender_RotationAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "alerts.jsonl")
+	fs := NewFileSender(config.FileConfig{Path: path, Rotate: "daily", MaxFiles: 2})
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	fs.now = func() time.Time { return now }
+
+	for day := 0; day < 4; day++ {
+		if err := fs.Send(context.Background(), Message{Text: "x"}); err != nil {
+			t.Fatalf("ошибка записи: %v", err)
+		}
+		// файл "написан" в текущий день, следующая запись - уже на следующий
+		if err := os.Chtimes(path, now, now); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(24 * time.Hour)
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(dir, "alerts-*.jsonl"))
+	if len(rotated) != 2 {
+		t.Fatalf("max_files=2: ожидается 2 архивных файла, получено %v", rotated)
+	}
+	if !strings.HasSuffix(rotated[1], "alerts-20260303-120000.jsonl") {
+		t.Fatalf("ожидается архив за 3 марта, получено %v", rotated)
+	}
+	if n := len(readRecords(t, path)); n != 1 {
+		t.Fatalf("в текущем файле ожидается 1 запись, получено %d", n)
+	}
+}
+
+func TestFileSender_RotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "alerts.jsonl")
+	fs := NewFileSender(config.FileConfig{Path: path})
+	fs.maxSize = 300 // в тестах мегабайт слишком много
+
+	for i := 0; i < 5; i++ {
+		if err := fs.Send(context.Background(), Message{Text: strings.Repeat("a", 100)}); err != nil {
+			t.Fatalf("ошибка записи: %v", err)
+		}
+	}
+
+	rotated, _ := filepath.Glob(filepath.Join(dir, "alerts-*.jsonl"))
+	if len(rotated) == 0 {
+		t.Fatal("ожидается ротация по размеру")
+	}
+	if info, _ := os.Stat(path); info.Size() > 300 {
+		t.Fatalf("текущий файл больше лимита: %d", info.Size())
+	}
+}
+
+func TestFileSender_RetentionKeepsOtherDestinationFiles(t *testing.T) {
+	dir := t.TempDir()
+	// архив получателя a-b подходит под "alerts.a-*.jsonl", но принадлежит не a
+	other := []string{
+		filepath.Join(dir, "alerts.a-b.jsonl"),
+		filepath.Join(dir, "alerts.a-b-20260101-120000.jsonl"),
+	}
+	for _, p := range other {
+		if err := os.WriteFile(p, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "alerts.a.jsonl")
+	fs := NewFileSender(config.FileConfig{Path: path, MaxFiles: 1})
+	fs.maxSize = 1
+	for i := 0; i < 3; i++ {
+		if err := fs.Send(context.Background(), Message{Text: "x"}); err != nil {
+			t.Fatalf("ошибка записи: %v", err)
+		}
+	}
+
+	for _, p := range other {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("ротация получателя a удалила чужой файл %s: %v", p, err)
+		}
+	}
+	own, _ := filepath.Glob(filepath.Join(dir, "alerts.a-2*.jsonl"))
+	if len(own) != 1 {
+		t.Fatalf("max_files=1: ожидается 1 свой архив, получено %v", own)
+	}
+}
+
+func TestFileSender_DeliveryRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.jsonl")
+	fs := NewFileSender(config.FileConfig{Path: path})
+
+	entry := incidentEntry(t, "2026-03-01T10:00:00Z [ERROR] db timeout")
+	msg := Message{Entry: entry, Event: EventDelivery, Delivery: &Delivery{Destination: "oncall", Status: "dropped", Error: "chat not found"}}
+	if err := fs.Send(context.Background(), msg); err != nil {
+		t.Fatalf("ошибка записи: %v", err)
+	}
+
+	recs := readRecords(t, path)
+	if len(recs) != 1 {
+		t.Fatalf("ожидается одна запись, получено %d", len(recs))
+	}
+	r := recs[0]
+	if r.Kind != "delivery" || r.Destination != "oncall" || r.Status != "dropped" || r.Error != "chat not found" || r.Fingerprint != entry.Fingerprint {
+		t.Fatalf("неверная запись итога доставки: %+v", r)
+	}
+}
@@ -0,0 +1,65 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// postJSON отправляет JSON и превращает ответ не из 2xx в APIError
+func postJSON(ctx context.Context, client *http.Client, kind, url string, headers map[string]string, payload any) error {
+	return doJSON(ctx, client, kind, http.MethodPost, url, headers, payload, nil)
+}
+
+// doJSON HTTP-запрос с JSON-телом (если in не nil); ответ 2xx декодируется в out (если out не nil),
+// остальные ответы превращаются в APIError
+func doJSON(ctx context.Context, client *http.Client, kind, method, url string, headers map[string]string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("ошибка кодирования в формат JSON: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса %s: %w", kind, err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось выполнить запрос в %s: %w", kind, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if out == nil {
+			io.Copy(io.Discard, resp.Body)
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("ошибка декодирования ответа %s: %w", kind, err)
+		}
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("ошибка api %s: %w", kind, &APIError{
+		StatusCode:  resp.StatusCode,
+		Description: strings.TrimSpace(string(snippet)),
+		RetryAfter:  parseRetryAfter(resp.Header.Get("Retry-After")),
+	})
+}
@@ -0,0 +1,58 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"log"
+	"os"
+	"strings"
+)
+
+// Общие части отправителей в системы дежурств (PagerDuty, Opsgenie)
+
+// incidentKey ключ инцидента, чтобы повторы и resolve попадали в тот же инцидент: ключ дедупликации по group_by
+// или правило и нормализованное сообщение. Fingerprint исходной строки уникален для каждой строки и не подходит.
+func incidentKey(entry *log_processing.LogEntry) string {
+	return protect_from_duplicates.StableKey(*entry)
+}
+
+// incidentSummary короткое описание инцидента: уровень, правило и сообщение
+func incidentSummary(entry *log_processing.LogEntry, limit int) string {
+	s := "[" + entry.Level + "] "
+	if entry.Rule != "" {
+		s += entry.Rule + ": "
+	}
+	s += entry.Message
+	if r := []rune(s); len(r) > limit {
+		s = string(r[:limit-1]) + "…"
+	}
+	return s
+}
+
+// upperKeys ключи-уровни в верхнем регистре, как уровни в LogEntry
+func upperKeys(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[strings.ToUpper(strings.TrimSpace(k))] = v
+	}
+	return out
+}
+
+func defaultSource(source string) string {
+	if source != "" {
+		return source
+	}
+	if host, err := os.Hostname(); err == nil {
+		return host
+	}
+	return "bug-tracking-bot"
+}
+
+// skipNotice служебные сообщения (сводки silences и окон) не должны открывать инциденты
+func skipNotice(kind string, msg Message) bool {
+	if msg.Entry != nil {
+		return false
+	}
+	log.Printf("Служебное сообщение не отправляется в %s", kind)
+	return true
+}
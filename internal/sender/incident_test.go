@@ -0,0 +1,131 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type capturedRequest struct {
+	path string
+	auth string
+	body map[string]any
+}
+
+func captureServer(t *testing.T, status int) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+	var reqs []capturedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		c := capturedRequest{path: r.URL.RequestURI(), auth: r.Header.Get("Authorization")}
+		_ = json.Unmarshal(b, &c.body)
+		reqs = append(reqs, c)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+// incidentEntry запись, как её видит sender после парсера, matcher и дедупликации без group_by
+func incidentEntry(t *testing.T, line string) *log_processing.LogEntry {
+	t.Helper()
+	entry, err := parser.ParseLine(line)
+	if err != nil {
+		t.Fatalf("ошибка разбора строки: %v", err)
+	}
+	entry.Rule = "database"
+	entry.Fingerprint = protect_from_duplicates.Key(entry, nil)
+	return &entry
+}
+
+func TestPagerDutySender_TriggerAndResolve(t *testing.T) {
+	srv, reqs := captureServer(t, http.StatusAccepted)
+
+	pd, err := NewPagerDutySender(config.PagerDutyConfig{
+		RoutingKey: "key", BaseURL: srv.URL, Source: "app-01",
+		Severities: map[string]string{"error": "critical"},
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	first := incidentEntry(t, "2026-03-01T10:00:00Z [ERROR] db timeout after 30s")
+	repeat := incidentEntry(t, "2026-03-01T10:07:00Z [ERROR] db timeout after 31s")
+	for _, entry := range []*log_processing.LogEntry{first, repeat} {
+		if err := pd.Send(context.Background(), Message{Text: "text", Entry: entry}); err != nil {
+			t.Fatalf("trigger: %v", err)
+		}
+	}
+	if err := pd.Send(context.Background(), Message{Entry: repeat, Event: EventResolve}); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if err := pd.Send(context.Background(), Message{Text: "сводка"}); err != nil {
+		t.Fatalf("служебное сообщение: %v", err)
+	}
+
+	if len(*reqs) != 3 {
+		t.Fatalf("ожидается 3 запроса (служебное сообщение пропускается), получено %d", len(*reqs))
+	}
+	trigger, again, resolve := (*reqs)[0], (*reqs)[1], (*reqs)[2]
+	key := trigger.body["dedup_key"]
+	if trigger.path != "/v2/enqueue" || trigger.body["event_action"] != "trigger" || key == nil || key == first.Fingerprint {
+		t.Fatalf("неверный trigger: %s %v", trigger.path, trigger.body)
+	}
+	if again.body["dedup_key"] != key {
+		t.Fatalf("повтор той же ошибки в другое время должен попадать в тот же инцидент: %v и %v", key, again.body["dedup_key"])
+	}
+	payload := trigger.body["payload"].(map[string]any)
+	if payload["severity"] != "critical" || payload["source"] != "app-01" || payload["summary"] != "[ERROR] database: db timeout after 30s" {
+		t.Fatalf("неверный payload: %v", payload)
+	}
+	if resolve.body["event_action"] != "resolve" || resolve.body["dedup_key"] != key || resolve.body["payload"] != nil {
+		t.Fatalf("неверный resolve: %v", resolve.body)
+	}
+}
+
+func TestOpsgenieSender_CreateAndClose(t *testing.T) {
+	srv, reqs := captureServer(t, http.StatusAccepted)
+
+	og, err := NewOpsgenieSender(config.OpsgenieConfig{APIKey: "genie", BaseURL: srv.URL, Tags: []string{"backend"}})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	// ключ по group_by используется как есть и экранируется в URL
+	entry := incidentEntry(t, "2026-03-01T10:00:00Z [ERROR] db timeout after 30s")
+	entry.Fingerprint = "fp/with space"
+	if err := og.Send(context.Background(), Message{Text: "text", Entry: entry}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := og.Send(context.Background(), Message{Entry: entry, Event: EventResolve}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	create, closeReq := (*reqs)[0], (*reqs)[1]
+	if create.path != "/v2/alerts" || create.auth != "GenieKey genie" {
+		t.Fatalf("неверный запрос создания: %s %q", create.path, create.auth)
+	}
+	if create.body["alias"] != "fp/with space" || create.body["priority"] != "P2" || create.body["description"] != "text" {
+		t.Fatalf("неверный алерт: %v", create.body)
+	}
+	if closeReq.path != "/v2/alerts/fp%2Fwith%20space/close?identifierType=alias" {
+		t.Fatalf("неверный запрос закрытия: %s", closeReq.path)
+	}
+}
+
+func TestOpsgenieSender_ServerErrorIsRetryable(t *testing.T) {
+	srv, _ := captureServer(t, http.StatusServiceUnavailable)
+	og, _ := NewOpsgenieSender(config.OpsgenieConfig{APIKey: "genie", BaseURL: srv.URL})
+
+	err := og.Send(context.Background(), Message{Text: "text", Entry: incidentEntry(t, "2026-03-01T10:00:00Z [ERROR] db timeout")})
+	if err == nil || !IsRetryable(err) {
+		t.Fatalf("ожидается временная ошибка, получено: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IncomingWebhookSender входящий вебхук Slack или Mattermost.
+// Тело запроса - JSON, который подготовил formatter получателя (FormatSlack / FormatMattermost);
+// username, channel и иконка из конфига добавляются в него перед отправкой.
+type IncomingWebhookSender struct {
+	kind      string // slack | mattermost, для сообщений об ошибках
+	url       string
+	overrides map[string]string
+	client    *http.Client
+}
+
+func NewIncomingWebhookSender(kind string, cfg config.IncomingWebhookConfig) (*IncomingWebhookSender, error) {
+	if cfg.WebhookURL == "" {
+		return nil, fmt.Errorf("не задан webhook_url для %s", kind)
+	}
+
+	overrides := make(map[string]string)
+	for k, v := range map[string]string{
+		"username":   cfg.Username,
+		"channel":    cfg.Channel,
+		"icon_emoji": cfg.IconEmoji,
+		"icon_url":   cfg.IconURL,
+	} {
+		if v != "" {
+			overrides[k] = v
+		}
+	}
+
+	return &IncomingWebhookSender{
+		kind:      kind,
+		url:       cfg.WebhookURL,
+		overrides: overrides,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *IncomingWebhookSender) Send(ctx context.Context, msg Message) error {
+	payload := make(map[string]any)
+	if err := json.Unmarshal([]byte(msg.Text), &payload); err != nil {
+		// не JSON: отправляем как простой текст
+		payload = map[string]any{"text": msg.Text}
+	}
+	for k, v := range s.overrides {
+		payload[k] = v
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования в формат JSON: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса %s: %w", s.kind, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось выполнить запрос в %s: %w", s.kind, err)
+	}
+	defer resp.Body.Close()
+
+	// Slack отвечает "ok", Mattermost - пустым телом; ошибки приходят текстом (invalid_payload, no_service)
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	return fmt.Errorf("ошибка api %s: %w", s.kind, &APIError{
+		StatusCode:  resp.StatusCode,
+		Description: strings.TrimSpace(string(body)),
+		RetryAfter:  parseRetryAfter(resp.Header.Get("Retry-After")),
+	})
+}
@@ -0,0 +1,279 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const issueTitleLimit = 250
+
+// issueTracker API конкретного трекера задач
+type issueTracker interface {
+	create(ctx context.Context, title, body string) (issueRef, error)
+	comment(ctx context.Context, id, body string) error
+	isClosed(ctx context.Context, id string) (bool, error)
+	reopen(ctx context.Context, id string) error
+	markup() issueMarkup
+}
+
+// issueRef созданная задача: id для API (номер, iid или ключ Jira) и ссылка для людей
+type issueRef struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// issueMarkup разметка тела задачи: Markdown для GitHub/GitLab, wiki-разметка для Jira
+type issueMarkup struct {
+	bold  func(string) string
+	code  func(string) string
+	block func(string) string
+}
+
+var markdownIssueMarkup = issueMarkup{
+	bold:  func(s string) string { return "**" + s + "**" },
+	code:  func(s string) string { return "`" + strings.ReplaceAll(s, "`", "'") + "`" },
+	block: func(s string) string { return "```\n" + strings.ReplaceAll(s, "```", "'''") + "\n```" },
+}
+
+// issueRecord связь fingerprint -> задача и статистика повторов
+type issueRecord struct {
+	Issue     issueRef  `json:"issue"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     int       `json:"count"`
+	Samples   []string  `json:"samples,omitempty"`
+
+	// последний комментарий о повторах (или создание задачи) и счётчик на тот момент
+	CommentedAt    time.Time `json:"commented_at"`
+	CommentedCount int       `json:"commented_count"`
+}
+
+// IssueSender заводит задачу в трекере на каждый новый fingerprint,
+// повторы добавляются комментарием к уже открытой задаче
+type IssueSender struct {
+	tracker  issueTracker
+	reopen   bool
+	samples  int
+	interval time.Duration // не чаще одного комментария за interval; 0 - на каждый повтор
+	now      func() time.Time
+
+	mu     sync.Mutex
+	path   string
+	issues map[string]*issueRecord
+}
+
+func NewIssueSender(cfg config.IssuesConfig) (*IssueSender, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if cfg.TimeoutMS > 0 {
+		client.Timeout = time.Duration(cfg.TimeoutMS) * time.Millisecond
+	}
+
+	var tracker issueTracker
+	switch cfg.Provider {
+	case "github":
+		tracker = newGitHubTracker(cfg, client)
+	case "gitlab":
+		tracker = newGitLabTracker(cfg, client)
+	case "jira":
+		tracker = newJiraTracker(cfg, client)
+	default:
+		return nil, fmt.Errorf("не поддерживаемый трекер задач: %s", cfg.Provider)
+	}
+
+	s := &IssueSender{
+		tracker: tracker,
+		reopen:  cfg.Reopen,
+		samples: cfg.SampleLines,
+		now:     time.Now,
+		path:    cfg.StateFile,
+		issues:  make(map[string]*issueRecord),
+	}
+	if s.samples <= 0 {
+		s.samples = 5
+	}
+	if cfg.CommentIntervalSec > 0 {
+		s.interval = time.Duration(cfg.CommentIntervalSec) * time.Second
+	}
+
+	b, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения файла задач: %w", err)
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &s.issues); err != nil {
+			return nil, fmt.Errorf("ошибка декодирования файла задач %s: %w", s.path, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *IssueSender) Send(ctx context.Context, msg Message) error {
+	if skipNotice("трекер задач", msg) {
+		return nil
+	}
+	entry := msg.Entry
+	key := incidentKey(entry)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.issues[key]
+	if msg.Event == EventResolve {
+		if !ok {
+			return nil
+		}
+		m := s.tracker.markup()
+		return s.tracker.comment(ctx, rec.Issue.ID, m.bold("Восстановление")+" в "+entry.Timestamp.Format("2006-01-02 15:04:05"))
+	}
+
+	if ok {
+		err := s.recur(ctx, rec, entry)
+		if !isNotFound(err) {
+			return err
+		}
+		// задачу удалили в трекере: заводим новую
+		log.Printf("Задача %s для %s не найдена в трекере, создаём новую", rec.Issue.ID, key)
+		delete(s.issues, key)
+	}
+
+	return s.open(ctx, key, entry)
+}
+
+// open создаёт задачу для нового fingerprint
+func (s *IssueSender) open(ctx context.Context, key string, entry *log_processing.LogEntry) error {
+	m := s.tracker.markup()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n\n", m.bold("Сообщение:"), protect_from_duplicates.Normalize(entry.Message))
+	fmt.Fprintf(&b, "%s %s\n", m.bold("Уровень:"), entry.Level)
+	if entry.Rule != "" {
+		fmt.Fprintf(&b, "%s %s\n", m.bold("Правило:"), entry.Rule)
+	}
+	if entry.Source != "" {
+		fmt.Fprintf(&b, "%s %s\n", m.bold("Источник:"), entry.Source)
+	}
+	fmt.Fprintf(&b, "%s %s\n", m.bold("Впервые:"), entry.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "%s 1\n", m.bold("Количество:"))
+	fmt.Fprintf(&b, "%s %s\n\n", m.bold("Уникальный ключ:"), m.code(key))
+	fmt.Fprintf(&b, "%s\n%s\n", m.bold("Пример строки:"), m.block(entry.Raw))
+
+	ref, err := s.tracker.create(ctx, incidentSummary(entry, issueTitleLimit), b.String())
+	if err != nil {
+		return err
+	}
+	log.Printf("Создана задача %s для %s", ref.URL, key)
+
+	s.issues[key] = &issueRecord{
+		Issue:          ref,
+		FirstSeen:      entry.Timestamp,
+		LastSeen:       entry.Timestamp,
+		Count:          1,
+		Samples:        []string{entry.Raw},
+		CommentedAt:    s.now(),
+		CommentedCount: 1,
+	}
+	s.saveLocked()
+	return nil
+}
+
+// recur повтор: переоткрывает закрытую задачу (если включено) и добавляет комментарий.
+// Комментарии не чаще одного за comment_interval_sec, чтобы частая ошибка не упиралась в лимиты API трекера:
+// в промежутке повтор только увеличивает счётчик, а следующий комментарий сообщает, сколько повторов было с прошлого.
+// Пока примеров меньше sample_lines, в комментарий попадает исходная строка, дальше - только счётчик.
+func (s *IssueSender) recur(ctx context.Context, rec *issueRecord, entry *log_processing.LogEntry) error {
+	now := s.now()
+	if s.interval > 0 && now.Sub(rec.CommentedAt) < s.interval {
+		rec.Count++
+		rec.LastSeen = entry.Timestamp
+		s.saveLocked()
+		return nil
+	}
+
+	if s.reopen {
+		closed, err := s.tracker.isClosed(ctx, rec.Issue.ID)
+		if err != nil {
+			return err
+		}
+		if closed {
+			switch err := s.tracker.reopen(ctx, rec.Issue.ID); {
+			case err == nil:
+				log.Printf("Задача %s переоткрыта", rec.Issue.URL)
+			case IsRetryable(err):
+				return err
+			default:
+				// например, в Jira нет нужного перехода: комментарий всё равно оставляем
+				log.Printf("Не удалось переоткрыть задачу %s: %v", rec.Issue.URL, err)
+			}
+		}
+	}
+
+	m := s.tracker.markup()
+	count := rec.Count + 1
+	body := fmt.Sprintf("%s %s, всего %d раз (впервые %s)",
+		m.bold("Повтор"), entry.Timestamp.Format("2006-01-02 15:04:05"), count, rec.FirstSeen.Format("2006-01-02 15:04:05"))
+	if since := count - rec.CommentedCount; since > 1 {
+		body += fmt.Sprintf(", с прошлого комментария %d раз", since)
+	}
+	withSample := len(rec.Samples) < s.samples
+	if withSample {
+		body += "\n\n" + m.block(entry.Raw)
+	}
+
+	if err := s.tracker.comment(ctx, rec.Issue.ID, body); err != nil {
+		return err
+	}
+
+	rec.Count = count
+	rec.LastSeen = entry.Timestamp
+	rec.CommentedAt = now
+	rec.CommentedCount = count
+	if withSample {
+		rec.Samples = append(rec.Samples, entry.Raw)
+	}
+	s.saveLocked()
+	return nil
+}
+
+// saveLocked сохраняет связи fingerprint -> задача. Ошибку только логируем:
+// задача в трекере уже создана, повтор отправки завёл бы дубликат.
+func (s *IssueSender) saveLocked() {
+	b, err := json.MarshalIndent(s.issues, "", "  ")
+	if err != nil {
+		log.Printf("Ошибка кодирования файла задач: %v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		log.Printf("Ошибка создания временного файла задач: %v", err)
+		return
+	}
+	_, werr := tmp.Write(b)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		log.Printf("Ошибка записи файла задач: %v", errors.Join(werr, cerr))
+		return
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("Ошибка сохранения файла задач: %v", err)
+	}
+}
+
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusGone)
+}
@@ -0,0 +1,195 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGitHub хранит задачи в памяти и отвечает как GitHub Issues API
+type fakeGitHub struct {
+	mu       sync.Mutex
+	created  []map[string]any
+	comments map[string][]string
+	state    map[string]string
+	calls    []string
+}
+
+func newFakeGitHub(t *testing.T) (*fakeGitHub, *httptest.Server) {
+	f := &fakeGitHub{comments: map[string][]string{}, state: map[string]string{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+
+		var body map[string]any
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &body)
+
+		path := strings.TrimPrefix(r.URL.Path, "/repos/acme/shop/issues")
+		switch {
+		case r.Method == http.MethodPost && path == "":
+			f.created = append(f.created, body)
+			n := len(f.created)
+			f.state[strconv.Itoa(n)] = "open"
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"number": n, "html_url": "https://github.test/acme/shop/issues/" + strconv.Itoa(n)})
+		case r.Method == http.MethodPost && strings.HasSuffix(path, "/comments"):
+			id := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/comments")
+			if _, ok := f.state[id]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			f.comments[id] = append(f.comments[id], body["body"].(string))
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]string{"state": f.state[strings.TrimPrefix(path, "/")]})
+		case r.Method == http.MethodPatch:
+			f.state[strings.TrimPrefix(path, "/")] = body["state"].(string)
+		default:
+			http.Error(w, "unexpected", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func TestIssueSender_CreateCommentReopen(t *testing.T) {
+	gh, srv := newFakeGitHub(t)
+	cfg := config.IssuesConfig{
+		Provider: "github", BaseURL: srv.URL, Token: "tkn", Project: "acme/shop",
+		Labels: []string{"bug"}, Reopen: true, SampleLines: 2,
+		StateFile: filepath.Join(t.TempDir(), "issues.json"),
+	}
+	is, err := NewIssueSender(cfg)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	// та же ошибка в разное время: строки и их fingerprint по исходной строке различаются
+	entry := incidentEntry(t, "2026-01-01T00:00:00Z [ERROR] order 123 failed")
+	repeats := []*log_processing.LogEntry{
+		incidentEntry(t, "2026-01-01T00:10:00Z [ERROR] order 456 failed"),
+		incidentEntry(t, "2026-01-01T00:20:00Z [ERROR] order 789 failed"),
+	}
+	ctx := context.Background()
+
+	if err := is.Send(ctx, Message{Entry: entry}); err != nil {
+		t.Fatalf("создание: %v", err)
+	}
+	if len(gh.created) != 1 {
+		t.Fatalf("ожидается одна задача, получено %d", len(gh.created))
+	}
+	issue := gh.created[0]
+	if issue["title"] != "[ERROR] database: order 123 failed" || !strings.Contains(issue["body"].(string), "order <n> failed") {
+		t.Fatalf("неверная задача: %v", issue)
+	}
+	if labels, _ := issue["labels"].([]any); len(labels) != 1 || labels[0] != "bug" {
+		t.Fatalf("ожидается метка bug: %v", issue["labels"])
+	}
+
+	// задачу закрыли, повтор должен её переоткрыть и оставить комментарий
+	gh.state["1"] = "closed"
+	is2, err := NewIssueSender(cfg) // связь fingerprint -> задача читается из файла
+	if err != nil {
+		t.Fatalf("повторное открытие: %v", err)
+	}
+	for _, r := range repeats {
+		if err := is2.Send(ctx, Message{Entry: r}); err != nil {
+			t.Fatalf("повтор: %v", err)
+		}
+	}
+
+	if len(gh.created) != 1 {
+		t.Fatalf("повтор не должен создавать новую задачу, создано %d", len(gh.created))
+	}
+	if gh.state["1"] != "open" {
+		t.Fatal("закрытая задача должна быть переоткрыта")
+	}
+	comments := gh.comments["1"]
+	if len(comments) != 2 || !strings.Contains(comments[0], "всего 2 раз") || !strings.Contains(comments[1], "всего 3 раз") {
+		t.Fatalf("неверные комментарии: %q", comments)
+	}
+	// sample_lines=2: первая строка в задаче, вторая в первом комментарии, дальше только счётчик
+	if !strings.Contains(comments[0], "```") || strings.Contains(comments[1], "```") {
+		t.Fatalf("пример строки ожидается только в первом комментарии: %q", comments)
+	}
+}
+
+func TestIssueSender_RecreatesDeletedIssue(t *testing.T) {
+	gh, srv := newFakeGitHub(t)
+	is, _ := NewIssueSender(config.IssuesConfig{
+		Provider: "github", BaseURL: srv.URL, Token: "tkn", Project: "acme/shop", SampleLines: 5,
+		StateFile: filepath.Join(t.TempDir(), "issues.json"),
+	})
+
+	entry := incidentEntry(t, "2026-03-01T10:00:00Z [ERROR] db timeout")
+	ctx := context.Background()
+	if err := is.Send(ctx, Message{Entry: entry}); err != nil {
+		t.Fatalf("создание: %v", err)
+	}
+	delete(gh.state, "1") // задачу удалили в трекере
+
+	if err := is.Send(ctx, Message{Entry: entry}); err != nil {
+		t.Fatalf("повтор: %v", err)
+	}
+	if len(gh.created) != 2 {
+		t.Fatalf("ожидается новая задача вместо удалённой, создано %d", len(gh.created))
+	}
+}
+
+func TestIssueSender_ThrottlesComments(t *testing.T) {
+	gh, srv := newFakeGitHub(t)
+	is, err := NewIssueSender(config.IssuesConfig{
+		Provider: "github", BaseURL: srv.URL, Token: "tkn", Project: "acme/shop", Reopen: true, SampleLines: 5,
+		CommentIntervalSec: 600, StateFile: filepath.Join(t.TempDir(), "issues.json"),
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	is.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	send := func(line string) {
+		t.Helper()
+		if err := is.Send(ctx, Message{Entry: incidentEntry(t, line)}); err != nil {
+			t.Fatalf("отправка: %v", err)
+		}
+	}
+	send("2026-03-01T10:00:00Z [ERROR] order 1 failed")
+	for i, line := range []string{
+		"2026-03-01T10:01:00Z [ERROR] order 2 failed",
+		"2026-03-01T10:02:00Z [ERROR] order 3 failed",
+		"2026-03-01T10:03:00Z [ERROR] order 4 failed",
+	} {
+		now = now.Add(time.Duration(i+1) * time.Minute)
+		send(line)
+	}
+	if n := len(gh.comments["1"]); n != 0 {
+		t.Fatalf("в пределах comment_interval_sec комментариев быть не должно, получено %d", n)
+	}
+	gh.mu.Lock()
+	calls := len(gh.calls)
+	gh.mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("повторы в пределах интервала не должны обращаться к трекеру, запросов: %d", calls)
+	}
+
+	now = now.Add(10 * time.Minute)
+	send("2026-03-01T10:15:00Z [ERROR] order 5 failed")
+	comments := gh.comments["1"]
+	if len(comments) != 1 || !strings.Contains(comments[0], "всего 5 раз") || !strings.Contains(comments[0], "с прошлого комментария 4 раз") {
+		t.Fatalf("ожидается один комментарий со сводным счётчиком, получено %q", comments)
+	}
+}
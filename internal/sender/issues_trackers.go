@@ -0,0 +1,220 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GitHub Issues REST API
+
+type gitHubTracker struct {
+	base    string // https://api.github.com/repos/owner/repo
+	labels  []string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newGitHubTracker(cfg config.IssuesConfig, client *http.Client) *gitHubTracker {
+	base := cfg.BaseURL
+	if base == "" {
+		base = "https://api.github.com"
+	}
+	return &gitHubTracker{
+		base:   strings.TrimSuffix(base, "/") + "/repos/" + cfg.Project,
+		labels: cfg.Labels,
+		headers: map[string]string{
+			"Authorization": "Bearer " + cfg.Token,
+			"Accept":        "application/vnd.github+json",
+		},
+		client: client,
+	}
+}
+
+func (t *gitHubTracker) create(ctx context.Context, title, body string) (issueRef, error) {
+	var resp struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	in := map[string]any{"title": title, "body": body}
+	if len(t.labels) > 0 {
+		in["labels"] = t.labels
+	}
+	if err := doJSON(ctx, t.client, "github", http.MethodPost, t.base+"/issues", t.headers, in, &resp); err != nil {
+		return issueRef{}, err
+	}
+	return issueRef{ID: strconv.Itoa(resp.Number), URL: resp.HTMLURL}, nil
+}
+
+func (t *gitHubTracker) comment(ctx context.Context, id, body string) error {
+	return doJSON(ctx, t.client, "github", http.MethodPost, t.base+"/issues/"+id+"/comments", t.headers, map[string]string{"body": body}, nil)
+}
+
+func (t *gitHubTracker) isClosed(ctx context.Context, id string) (bool, error) {
+	var resp struct {
+		State string `json:"state"`
+	}
+	err := doJSON(ctx, t.client, "github", http.MethodGet, t.base+"/issues/"+id, t.headers, nil, &resp)
+	return resp.State == "closed", err
+}
+
+func (t *gitHubTracker) reopen(ctx context.Context, id string) error {
+	return doJSON(ctx, t.client, "github", http.MethodPatch, t.base+"/issues/"+id, t.headers, map[string]string{"state": "open"}, nil)
+}
+
+func (t *gitHubTracker) markup() issueMarkup { return markdownIssueMarkup }
+
+// GitLab Issues API v4
+
+type gitLabTracker struct {
+	base    string // https://gitlab.com/api/v4/projects/group%2Fproject
+	labels  string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newGitLabTracker(cfg config.IssuesConfig, client *http.Client) *gitLabTracker {
+	base := cfg.BaseURL
+	if base == "" {
+		base = "https://gitlab.com"
+	}
+	return &gitLabTracker{
+		base:    strings.TrimSuffix(base, "/") + "/api/v4/projects/" + url.PathEscape(cfg.Project),
+		labels:  strings.Join(cfg.Labels, ","),
+		headers: map[string]string{"PRIVATE-TOKEN": cfg.Token},
+		client:  client,
+	}
+}
+
+func (t *gitLabTracker) create(ctx context.Context, title, body string) (issueRef, error) {
+	var resp struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	in := map[string]string{"title": title, "description": body}
+	if t.labels != "" {
+		in["labels"] = t.labels
+	}
+	if err := doJSON(ctx, t.client, "gitlab", http.MethodPost, t.base+"/issues", t.headers, in, &resp); err != nil {
+		return issueRef{}, err
+	}
+	return issueRef{ID: strconv.Itoa(resp.IID), URL: resp.WebURL}, nil
+}
+
+func (t *gitLabTracker) comment(ctx context.Context, id, body string) error {
+	return doJSON(ctx, t.client, "gitlab", http.MethodPost, t.base+"/issues/"+id+"/notes", t.headers, map[string]string{"body": body}, nil)
+}
+
+func (t *gitLabTracker) isClosed(ctx context.Context, id string) (bool, error) {
+	var resp struct {
+		State string `json:"state"` // opened | closed
+	}
+	err := doJSON(ctx, t.client, "gitlab", http.MethodGet, t.base+"/issues/"+id, t.headers, nil, &resp)
+	return resp.State == "closed", err
+}
+
+func (t *gitLabTracker) reopen(ctx context.Context, id string) error {
+	return doJSON(ctx, t.client, "gitlab", http.MethodPut, t.base+"/issues/"+id, t.headers, map[string]string{"state_event": "reopen"}, nil)
+}
+
+func (t *gitLabTracker) markup() issueMarkup { return markdownIssueMarkup }
+
+// Jira REST API v2 (описание и комментарии - в wiki-разметке)
+
+type jiraTracker struct {
+	base       string // https://jira.example.com
+	project    string
+	issueType  string
+	labels     []string
+	transition string
+	headers    map[string]string
+	client     *http.Client
+}
+
+func newJiraTracker(cfg config.IssuesConfig, client *http.Client) *jiraTracker {
+	auth := "Bearer " + cfg.Token
+	if cfg.Username != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.Username+":"+cfg.Token))
+	}
+	return &jiraTracker{
+		base:       strings.TrimSuffix(cfg.BaseURL, "/"),
+		project:    cfg.Project,
+		issueType:  cfg.IssueType,
+		labels:     cfg.Labels,
+		transition: cfg.ReopenTransition,
+		headers:    map[string]string{"Authorization": auth},
+		client:     client,
+	}
+}
+
+func (t *jiraTracker) create(ctx context.Context, title, body string) (issueRef, error) {
+	fields := map[string]any{
+		"project":     map[string]string{"key": t.project},
+		"summary":     title,
+		"description": body,
+		"issuetype":   map[string]string{"name": t.issueType},
+	}
+	if len(t.labels) > 0 {
+		fields["labels"] = t.labels
+	}
+	var resp struct {
+		Key string `json:"key"`
+	}
+	if err := doJSON(ctx, t.client, "jira", http.MethodPost, t.base+"/rest/api/2/issue", t.headers, map[string]any{"fields": fields}, &resp); err != nil {
+		return issueRef{}, err
+	}
+	return issueRef{ID: resp.Key, URL: t.base + "/browse/" + resp.Key}, nil
+}
+
+func (t *jiraTracker) comment(ctx context.Context, id, body string) error {
+	return doJSON(ctx, t.client, "jira", http.MethodPost, t.base+"/rest/api/2/issue/"+id+"/comment", t.headers, map[string]string{"body": body}, nil)
+}
+
+func (t *jiraTracker) isClosed(ctx context.Context, id string) (bool, error) {
+	var resp struct {
+		Fields struct {
+			Status struct {
+				StatusCategory struct {
+					Key string `json:"key"` // new | indeterminate | done
+				} `json:"statusCategory"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	err := doJSON(ctx, t.client, "jira", http.MethodGet, t.base+"/rest/api/2/issue/"+id+"?fields=status", t.headers, nil, &resp)
+	return resp.Fields.Status.StatusCategory.Key == "done", err
+}
+
+// reopen в Jira статус меняется переходом; ищем переход по имени из reopen_transition
+func (t *jiraTracker) reopen(ctx context.Context, id string) error {
+	var resp struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	u := t.base + "/rest/api/2/issue/" + id + "/transitions"
+	if err := doJSON(ctx, t.client, "jira", http.MethodGet, u, t.headers, nil, &resp); err != nil {
+		return err
+	}
+	for _, tr := range resp.Transitions {
+		if strings.EqualFold(tr.Name, t.transition) {
+			return doJSON(ctx, t.client, "jira", http.MethodPost, u, t.headers, map[string]any{"transition": map[string]string{"id": tr.ID}}, nil)
+		}
+	}
+	return fmt.Errorf("jira: у задачи %s нет перехода %q", id, t.transition)
+}
+
+func (t *jiraTracker) markup() issueMarkup {
+	return issueMarkup{
+		bold: func(s string) string { return "*" + s + "*" },
+		code: func(s string) string { return "{{" + s + "}}" },
+		block: func(s string) string {
+			return "{noformat}\n" + strings.ReplaceAll(s, "{noformat}", "") + "\n{noformat}"
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOpsgenieURL         = "https://api.opsgenie.com"
+	opsgenieMessageLimit       = 130
+	opsgenieDescriptionLimit   = 15000
+	opsgenieAliasLimit         = 512
+	opsgenieDefaultPriority    = "P3"
+	opsgenieResolveDescription = "Восстановление зафиксировано bug tracking bot"
+)
+
+// defaultOpsgeniePriorities уровень лога -> приоритет Opsgenie
+var defaultOpsgeniePriorities = map[string]string{
+	"FATAL": "P1",
+	"ERROR": "P2",
+	"WARN":  "P3",
+	"INFO":  "P4",
+	"DEBUG": "P5",
+}
+
+// OpsgenieSender Opsgenie Alert API: создание алерта и закрытие по alias при восстановлении.
+// alias = fingerprint, повторы Opsgenie сам склеивает в один алерт.
+type OpsgenieSender struct {
+	apiKey     string
+	baseURL    string
+	source     string
+	tags       []string
+	priorities map[string]string
+	client     *http.Client
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+func NewOpsgenieSender(cfg config.OpsgenieConfig) (*OpsgenieSender, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("не задан api_key для opsgenie")
+	}
+
+	base := cfg.BaseURL
+	if base == "" {
+		base = defaultOpsgenieURL
+	}
+
+	priorities := upperKeys(defaultOpsgeniePriorities)
+	for k, v := range upperKeys(cfg.Priorities) {
+		priorities[k] = v
+	}
+
+	s := &OpsgenieSender{
+		apiKey:     cfg.APIKey,
+		baseURL:    strings.TrimSuffix(base, "/"),
+		source:     defaultSource(cfg.Source),
+		tags:       cfg.Tags,
+		priorities: priorities,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	if cfg.TimeoutMS > 0 {
+		s.client.Timeout = time.Duration(cfg.TimeoutMS) * time.Millisecond
+	}
+	return s, nil
+}
+
+func (s *OpsgenieSender) Send(ctx context.Context, msg Message) error {
+	if skipNotice("opsgenie", msg) {
+		return nil
+	}
+	entry := msg.Entry
+	headers := map[string]string{"Authorization": "GenieKey " + s.apiKey}
+
+	alias := incidentKey(entry)
+	if r := []rune(alias); len(r) > opsgenieAliasLimit {
+		alias = string(r[:opsgenieAliasLimit])
+	}
+
+	if msg.Event == EventResolve {
+		u := s.baseURL + "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		return postJSON(ctx, s.client, "opsgenie", u, headers, opsgenieClose{Source: s.source, Note: opsgenieResolveDescription})
+	}
+
+	priority, ok := s.priorities[entry.Level]
+	if !ok {
+		priority = opsgenieDefaultPriority
+	}
+
+	description := msg.Text
+	if r := []rune(description); len(r) > opsgenieDescriptionLimit {
+		description = string(r[:opsgenieDescriptionLimit-1]) + "…"
+	}
+
+	details := map[string]string{"level": entry.Level, "raw": entry.Raw}
+	if entry.Rule != "" {
+		details["rule"] = entry.Rule
+	}
+	for k, v := range entry.Fields {
+		details[k] = v
+	}
+
+	alert := opsgenieAlert{
+		Message:     incidentSummary(entry, opsgenieMessageLimit),
+		Alias:       alias,
+		Description: description,
+		Priority:    priority,
+		Source:      s.source,
+		Tags:        s.tags,
+		Details:     details,
+	}
+	return postJSON(ctx, s.client, "opsgenie", s.baseURL+"/v2/alerts", headers, alert)
+}
@@ -0,0 +1,115 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPagerDutyURL      = "https://events.pagerduty.com"
+	pagerDutySummaryLimit    = 1024
+	pagerDutyDefaultSeverity = "error"
+)
+
+// defaultPagerDutySeverities уровень лога -> severity PagerDuty
+var defaultPagerDutySeverities = map[string]string{
+	"FATAL": "critical",
+	"ERROR": "error",
+	"WARN":  "warning",
+	"INFO":  "info",
+	"DEBUG": "info",
+}
+
+// PagerDutySender PagerDuty Events API v2: trigger на алерт, resolve на восстановление.
+// dedup_key = fingerprint, поэтому повторы не открывают новые инциденты.
+type PagerDutySender struct {
+	routingKey string
+	url        string
+	source     string
+	severities map[string]string
+	client     *http.Client
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger | resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"` // для resolve не нужен
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+func NewPagerDutySender(cfg config.PagerDutyConfig) (*PagerDutySender, error) {
+	if cfg.RoutingKey == "" {
+		return nil, fmt.Errorf("не задан routing_key для pagerduty")
+	}
+
+	base := cfg.BaseURL
+	if base == "" {
+		base = defaultPagerDutyURL
+	}
+
+	severities := upperKeys(defaultPagerDutySeverities)
+	for k, v := range upperKeys(cfg.Severities) {
+		severities[k] = v
+	}
+
+	s := &PagerDutySender{
+		routingKey: cfg.RoutingKey,
+		url:        strings.TrimSuffix(base, "/") + "/v2/enqueue",
+		source:     defaultSource(cfg.Source),
+		severities: severities,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	if cfg.TimeoutMS > 0 {
+		s.client.Timeout = time.Duration(cfg.TimeoutMS) * time.Millisecond
+	}
+	return s, nil
+}
+
+func (s *PagerDutySender) Send(ctx context.Context, msg Message) error {
+	if skipNotice("pagerduty", msg) {
+		return nil
+	}
+	entry := msg.Entry
+
+	ev := pagerDutyEvent{
+		RoutingKey:  s.routingKey,
+		EventAction: "trigger",
+		DedupKey:    incidentKey(entry),
+	}
+	if msg.Event == EventResolve {
+		ev.EventAction = "resolve"
+		return postJSON(ctx, s.client, "pagerduty", s.url, nil, ev)
+	}
+
+	severity, ok := s.severities[entry.Level]
+	if !ok {
+		severity = pagerDutyDefaultSeverity
+	}
+
+	details := map[string]string{"message": msg.Text, "raw": entry.Raw}
+	for k, v := range entry.Fields {
+		details[k] = v
+	}
+	ev.Payload = &pagerDutyPayload{
+		Summary:       incidentSummary(entry, pagerDutySummaryLimit),
+		Source:        s.source,
+		Severity:      severity,
+		Timestamp:     entry.Timestamp.Format(time.RFC3339),
+		Class:         entry.Rule,
+		CustomDetails: details,
+	}
+	return postJSON(ctx, s.client, "pagerduty", s.url, nil, ev)
+}
@@ -0,0 +1,78 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetrySender повторяет отправку при временных ошибках с экспоненциальной задержкой и джиттером.
+// Если API вернул retry_after, ждём не меньше него.
+type RetrySender struct {
+	next   Sender
+	policy config.RetryConfig
+	sleep  func(ctx context.Context, d time.Duration) error // подменяется в тестах
+}
+
+func NewRetrySender(next Sender, policy config.RetryConfig) *RetrySender {
+	return &RetrySender{next: next, policy: policy, sleep: sleepCtx}
+}
+
+func (s *RetrySender) Send(ctx context.Context, msg Message) error {
+	start := time.Now()
+	maxElapsed := time.Duration(s.policy.MaxElapsedMS) * time.Millisecond // -1 = без ограничения
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.next.Send(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !IsRetryable(err) {
+			return err
+		}
+		if attempt >= s.policy.MaxAttempts {
+			return fmt.Errorf("отправка не удалась после %d попыток: %w", attempt, err)
+		}
+
+		wait := s.backoff(attempt)
+		if ra := retryAfter(err); ra > wait {
+			wait = ra
+		}
+		if maxElapsed > 0 && time.Since(start)+wait > maxElapsed {
+			return fmt.Errorf("отправка не удалась за %s (%d попыток): %w", maxElapsed, attempt, err)
+		}
+
+		log.Printf("Ошибка отправки (попытка %d/%d), повтор через %s: %v", attempt, s.policy.MaxAttempts, wait.Round(time.Millisecond), err)
+		if err := s.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff задержка перед попыткой attempt+1: initial * multiplier^(attempt-1), не больше max, ± jitter
+func (s *RetrySender) backoff(attempt int) time.Duration {
+	d := float64(s.policy.InitialIntervalMS) * math.Pow(s.policy.Multiplier, float64(attempt-1))
+	if maxD := float64(s.policy.MaxIntervalMS); d > maxD {
+		d = maxD
+	}
+	if s.policy.Jitter > 0 { // -1 = без отклонения
+		d *= 1 + s.policy.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d) * time.Millisecond
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestTelegram(t *testing.T, handler http.HandlerFunc) *TelegramSender {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	tg, err := NewTelegramSender(config.TelegramConfig{BotToken: "token", ChatID: "1"})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	tg.baseURL = srv.URL
+	return tg
+}
+
+func testRetryPolicy() config.RetryConfig {
+	return config.RetryConfig{MaxAttempts: 3, InitialIntervalMS: 10, MaxIntervalMS: 100, Multiplier: 2, MaxElapsedMS: 120_000}
+}
+
+func TestRetrySender_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	tg := newTestTelegram(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	var slept []time.Duration
+	rs := NewRetrySender(tg, testRetryPolicy())
+	rs.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	if err := rs.Send(context.Background(), Message{Text: "test"}); err != nil {
+		t.Fatalf("ожидается успешная отправка со второй попытки, получено: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("ожидается 2 запроса, получено %d", calls.Load())
+	}
+	if len(slept) != 1 || slept[0] != 7*time.Second {
+		t.Fatalf("ожидается ожидание retry_after=7s, получено %v", slept)
+	}
+}
+
+func TestRetrySender_NoRetryOnChatNotFound(t *testing.T) {
+	var calls atomic.Int32
+	tg := newTestTelegram(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+	})
+
+	rs := NewRetrySender(tg, testRetryPolicy())
+	rs.sleep = func(context.Context, time.Duration) error { return nil }
+
+	if err := rs.Send(context.Background(), Message{Text: "test"}); err == nil {
+		t.Fatal("ожидается ошибка")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("400 не должна повторяться, запросов: %d", calls.Load())
+	}
+}
+
+func TestIsRetryable_TLSCertificateIsPermanent(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// сертификат тестового сервера подписан неизвестным CA
+	_, err := http.Get(srv.URL)
+	if err == nil {
+		t.Fatal("ожидается ошибка проверки сертификата")
+	}
+	if IsRetryable(err) {
+		t.Fatalf("ошибка сертификата не должна повторяться: %v", err)
+	}
+
+	// обычная сетевая ошибка по-прежнему временная
+	addr := srv.Listener.Addr().String()
+	srv.Close()
+	if _, err := http.Get("http://" + addr); err == nil || !IsRetryable(err) {
+		t.Fatalf("отказ в соединении должен повторяться: %v", err)
+	}
+}
+
+func TestRetrySender_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	tg := newTestTelegram(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`<html>bad gateway</html>`))
+	})
+
+	rs := NewRetrySender(tg, testRetryPolicy())
+	rs.sleep = func(context.Context, time.Duration) error { return nil }
+
+	if err := rs.Send(context.Background(), Message{Text: "test"}); err == nil {
+		t.Fatal("ожидается ошибка после исчерпания попыток")
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("ожидается 3 попытки, получено %d", calls.Load())
+	}
+}
+
+func TestRetrySender_MaxElapsed(t *testing.T) {
+	tg := newTestTelegram(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"ok":false,"description":"Too Many Requests","parameters":{"retry_after":3600}}`))
+	})
+
+	policy := testRetryPolicy()
+	policy.MaxElapsedMS = 1000
+	rs := NewRetrySender(tg, policy)
+	rs.sleep = func(context.Context, time.Duration) error {
+		t.Fatal("не должны ждать дольше max_elapsed")
+		return nil
+	}
+
+	if err := rs.Send(context.Background(), Message{Text: "test"}); err == nil {
+		t.Fatal("ожидается ошибка")
+	}
+}
+
+func TestRetrySender_NoLimitAndNoJitter(t *testing.T) {
+	var calls atomic.Int32
+	tg := newTestTelegram(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"ok":false,"description":"Too Many Requests","parameters":{"retry_after":3600}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	policy := testRetryPolicy()
+	policy.MaxElapsedMS = -1
+	policy.Jitter = -1
+	rs := NewRetrySender(tg, policy)
+	var waited time.Duration
+	rs.sleep = func(_ context.Context, d time.Duration) error {
+		waited = d
+		return nil
+	}
+
+	if err := rs.Send(context.Background(), Message{Text: "test"}); err != nil {
+		t.Fatalf("max_elapsed_ms=-1: ожидается повтор без ограничения по времени, получено: %v", err)
+	}
+	if waited != time.Hour {
+		t.Fatalf("ожидается ожидание retry_after 1h, получено %s", waited)
+	}
+	for attempt := 1; attempt <= 3; attempt++ {
+		if got, want := rs.backoff(attempt), time.Duration(min(10<<(attempt-1), 100))*time.Millisecond; got != want {
+			t.Fatalf("jitter=-1: задержка должна быть без отклонения, попытка %d: %s, ожидается %s", attempt, got, want)
+		}
+	}
+}
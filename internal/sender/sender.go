@@ -2,22 +2,132 @@ package sender
 
 import (
 	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
 	"context"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// EventResolve событие восстановления: PagerDuty и Opsgenie закрывают инцидент с тем же fingerprint,
+// остальные получатели отправляют сообщение как обычно
+const EventResolve = "resolve"
+
+// EventRepeat повторы уже отправленного алерта: Telegram с live_updates дописывает их счётчик
+// в первое сообщение, другим получателям такие события не маршрутизируются
+const EventRepeat = "repeat"
+
+// EventDelivery итог доставки алерта одному из получателей; такие сообщения идут только в архив (file)
+const EventDelivery = "delivery"
+
+// Message сообщение для одного получателя
+type Message struct {
+	Text   string                   `json:"text,omitempty"`   // уже отформатировано formatter'ом получателя
+	Entry  *log_processing.LogEntry `json:"entry,omitempty"`  // исходная запись; nil для служебных сообщений (сводки)
+	Event  string                   `json:"event,omitempty"`  // пусто = алерт, EventResolve = восстановление, EventRepeat = повторы, EventDelivery = итог доставки
+	Repeat *Repeat                  `json:"repeat,omitempty"` // для EventRepeat
+
+	Destinations []string  `json:"destinations,omitempty"` // все получатели алерта по маршрутам, для архива
+	Recipients   []string  `json:"recipients,omitempty"`   // chat_id для личных сообщений подписчикам, см. NewTelegramDirectSender
+	Delivery     *Delivery `json:"delivery,omitempty"`     // для EventDelivery
+}
+
+// Delivery итог доставки алерта получателю: доставлен или удалён из outbox без доставки
+type Delivery struct {
+	Destination string `json:"destination"`
+	Status      string `json:"status"` // sent | dropped
+	Error       string `json:"error,omitempty"`
+}
+
+// Repeat сколько раз алерт встречался с момента отправки первого сообщения
+type Repeat struct {
+	Count    int       `json:"count"` // вместе с первым
+	LastSeen time.Time `json:"last_seen"`
+}
+
 type Sender interface {
-	Send(ctx context.Context, text string) error
+	Send(ctx context.Context, msg Message) error
 }
 
-func New(cfg *config.Config) (Sender, error) {
-	switch strings.ToLower(cfg.Sender.Type) {
+// New создаёт отправителя для получателя; временные ошибки повторяются согласно retry
+func New(dest config.DestinationConfig, retry config.RetryConfig) (Sender, error) {
+	var snd Sender
+	switch strings.ToLower(dest.Type) {
 	case "stdout":
 		return &StdoutSender{}, nil
 	case "telegram":
-		return NewTelegramSender(cfg.Telegram)
+		tg, err := NewTelegramSender(dest.Telegram)
+		if err != nil {
+			return nil, err
+		}
+		snd = tg
+	case "webhook":
+		wh, err := NewWebhookSender(dest.Webhook)
+		if err != nil {
+			return nil, err
+		}
+		snd = wh
+	case "slack":
+		sl, err := NewIncomingWebhookSender("slack", dest.Slack)
+		if err != nil {
+			return nil, err
+		}
+		snd = sl
+	case "mattermost":
+		mm, err := NewIncomingWebhookSender("mattermost", dest.Mattermost)
+		if err != nil {
+			return nil, err
+		}
+		snd = mm
+	case "discord":
+		ds, err := NewDiscordSender(dest.Discord)
+		if err != nil {
+			return nil, err
+		}
+		snd = ds
+	case "smtp":
+		sm, err := NewSMTPSender(dest.SMTP)
+		if err != nil {
+			return nil, err
+		}
+		snd = sm
+	case "pagerduty":
+		pd, err := NewPagerDutySender(dest.PagerDuty)
+		if err != nil {
+			return nil, err
+		}
+		snd = pd
+	case "opsgenie":
+		og, err := NewOpsgenieSender(dest.Opsgenie)
+		if err != nil {
+			return nil, err
+		}
+		snd = og
+	case "issues":
+		is, err := NewIssueSender(dest.Issues)
+		if err != nil {
+			return nil, err
+		}
+		snd = is
+	case "file":
+		snd = NewFileSender(dest.File)
+	case "exec":
+		ex, err := NewExecSender(dest.Exec)
+		if err != nil {
+			return nil, err
+		}
+		snd = ex
 	default:
-		return nil, fmt.Errorf("не поддерживаемый тип отправления данных: %s", cfg.Sender.Type)
+		return nil, fmt.Errorf("не поддерживаемый тип отправления данных: %s", dest.Type)
+	}
+
+	return WithRetry(snd, retry), nil
+}
+
+// WithRetry оборачивает отправителя повтором временных ошибок, если retry.max_attempts больше 1
+func WithRetry(snd Sender, retry config.RetryConfig) Sender {
+	if retry.MaxAttempts <= 1 {
+		return snd
 	}
+	return NewRetrySender(snd, retry)
 }
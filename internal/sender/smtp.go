@@ -0,0 +1,296 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	defaultSubjectTemplate = "[{{.Level}}] {{if .Rule}}{{.Rule}}: {{end}}{{.Message}}"
+	noticeSubject          = "Bug tracking bot: служебное сообщение"
+	maxSubjectLen          = 200
+)
+
+// SMTPSender отправляет алерт письмом multipart/alternative (текст + HTML)
+type SMTPSender struct {
+	cfg     config.SMTPConfig
+	addr    string
+	from    string
+	to      []string
+	subject *template.Template
+	timeout time.Duration
+}
+
+func NewSMTPSender(cfg config.SMTPConfig) (*SMTPSender, error) {
+	if cfg.Host == "" || len(cfg.To) == 0 {
+		return nil, fmt.Errorf("не заданы host или получатели письма")
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный адрес from: %w", err)
+	}
+	to := make([]string, 0, len(cfg.To))
+	for _, addr := range cfg.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный адрес получателя %q: %w", addr, err)
+		}
+		to = append(to, a.Address)
+	}
+
+	src := cfg.SubjectTemplate
+	if src == "" {
+		src = defaultSubjectTemplate
+	}
+	tmpl, err := template.New("subject").Funcs(templateFuncs).Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора subject_template: %w", err)
+	}
+
+	timeout := time.Duration(cfg.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &SMTPSender{
+		cfg:     cfg,
+		addr:    net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:    from.Address,
+		to:      to,
+		subject: tmpl,
+		timeout: timeout,
+	}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	body, err := s.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if s.cfg.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp: сервер не поддерживает STARTTLS")
+		}
+		if err := c.StartTLS(s.tlsConfig()); err != nil {
+			return fmt.Errorf("smtp: ошибка STARTTLS: %w", err)
+		}
+	}
+
+	if auth := s.auth(); auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp: ошибка авторизации: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.from); err != nil {
+		return fmt.Errorf("smtp: ошибка MAIL FROM: %w", err)
+	}
+	for _, to := range s.to {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp: ошибка RCPT TO %s: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: ошибка DATA: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("smtp: ошибка передачи письма: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: сервер не принял письмо: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// dial подключается к серверу; при tls: tls соединение шифруется сразу (обычно порт 465)
+func (s *SMTPSender) dial(ctx context.Context) (*smtp.Client, error) {
+	d := &net.Dialer{}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp: не удалось подключиться к %s: %w", s.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if s.cfg.TLS == "tls" {
+		tlsConn := tls.Client(conn, s.tlsConfig())
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("smtp: ошибка TLS: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp: ошибка приветствия сервера: %w", err)
+	}
+	return c, nil
+}
+
+func (s *SMTPSender) tlsConfig() *tls.Config {
+	return &tls.Config{ServerName: s.cfg.Host, InsecureSkipVerify: s.cfg.InsecureSkipVerify}
+}
+
+func (s *SMTPSender) auth() smtp.Auth {
+	switch s.cfg.Auth {
+	case "plain":
+		return smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	case "login":
+		return &loginAuth{username: s.cfg.Username, password: s.cfg.Password}
+	default:
+		return nil
+	}
+}
+
+// buildMessage собирает письмо: заголовки и multipart/alternative с текстовой и HTML-частью
+func (s *SMTPSender) buildMessage(msg Message) ([]byte, error) {
+	var content struct {
+		Text string `json:"text"`
+		HTML string `json:"html"`
+	}
+	if err := json.Unmarshal([]byte(msg.Text), &content); err != nil || (content.Text == "" && content.HTML == "") {
+		// не JSON от FormatEmail: отправляем как простой текст
+		content.Text = msg.Text
+		content.HTML = ""
+	}
+
+	subject, err := s.renderSubject(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	var out bytes.Buffer
+	for _, kv := range [][2]string{
+		{"From", s.cfg.From},
+		{"To", strings.Join(s.cfg.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID(s.cfg.Host)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	} {
+		fmt.Fprintf(&out, "%s: %s\r\n", kv[0], kv[1])
+	}
+	out.WriteString("\r\n")
+
+	if err := writeQPPart(mw, "text/plain; charset=utf-8", content.Text); err != nil {
+		return nil, err
+	}
+	if content.HTML != "" {
+		if err := writeQPPart(mw, "text/html; charset=utf-8", content.HTML); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+func (s *SMTPSender) renderSubject(msg Message) (string, error) {
+	if msg.Entry == nil {
+		return noticeSubject, nil
+	}
+	var b strings.Builder
+	if err := s.subject.Execute(&b, msg.Entry); err != nil {
+		return "", fmt.Errorf("ошибка рендеринга subject_template: %w", err)
+	}
+	// переводы строк в теме сломали бы заголовки письма
+	subject := strings.Join(strings.Fields(b.String()), " ")
+	if r := []rune(subject); len(r) > maxSubjectLen {
+		subject = string(r[:maxSubjectLen-1]) + "…"
+	}
+	return subject, nil
+}
+
+func writeQPPart(mw *multipart.Writer, contentType, body string) error {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Transfer-Encoding", "quoted-printable")
+	pw, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(pw)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(host string) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), host)
+}
+
+// loginAuth механизм AUTH LOGIN, которого нет в net/smtp (нужен части корпоративных серверов)
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("smtp: AUTH LOGIN без TLS запрещён")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("smtp: неожиданный запрос сервера при AUTH LOGIN: %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
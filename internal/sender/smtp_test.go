@@ -0,0 +1,237 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP минимальный SMTP-сервер: принимает одно письмо и запоминает диалог
+type fakeSMTP struct {
+	ln       net.Listener
+	rcptCode int // ответ на RCPT TO, по умолчанию 250
+
+	auth  []string
+	from  string
+	rcpts []string
+	data  string
+	done  chan struct{}
+}
+
+func newFakeSMTP(t *testing.T, tlsCfg *tls.Config) *fakeSMTP {
+	t.Helper()
+	var ln net.Listener
+	var err error
+	if tlsCfg != nil {
+		ln, err = tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	} else {
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatalf("не удалось запустить fake SMTP: %v", err)
+	}
+	f := &fakeSMTP{ln: ln, rcptCode: 250, done: make(chan struct{})}
+	t.Cleanup(func() { ln.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeSMTP) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeSMTP) serve() {
+	defer close(f.done)
+	conn, err := f.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) { _ = tp.PrintfLine("%d %s", code, msg) }
+
+	reply(220, "fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250-fake")
+			_ = tp.PrintfLine("250 AUTH PLAIN LOGIN")
+		case "AUTH":
+			parts := strings.Fields(line)
+			if parts[1] == "LOGIN" {
+				reply(334, base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := tp.ReadLine()
+				reply(334, base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := tp.ReadLine()
+				f.auth = []string{"LOGIN", decodeB64(user), decodeB64(pass)}
+			} else {
+				f.auth = []string{"PLAIN", decodeB64(parts[2])}
+			}
+			reply(235, "ok")
+		case "MAIL":
+			f.from = line
+			reply(250, "ok")
+		case "RCPT":
+			f.rcpts = append(f.rcpts, line)
+			reply(f.rcptCode, "rcpt")
+		case "DATA":
+			reply(354, "go ahead")
+			b, _ := io.ReadAll(tp.DotReader())
+			f.data = string(b)
+			reply(250, "queued")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "unknown")
+		}
+	}
+}
+
+func decodeB64(s string) string {
+	b, _ := base64.StdEncoding.DecodeString(s)
+	return string(b)
+}
+
+func testSMTPEntry() *log_processing.LogEntry {
+	return &log_processing.LogEntry{
+		Timestamp: time.Now(),
+		Level:     "ERROR",
+		Message:   "оплата не прошла",
+		Rule:      "payments",
+	}
+}
+
+func TestSMTPSender_MultipartWithLoginAuth(t *testing.T) {
+	srv := newFakeSMTP(t, nil)
+
+	cfg := config.SMTPConfig{
+		Host: "127.0.0.1", Port: srv.port(), TLS: "none", Auth: "login",
+		Username: "bot", Password: "secret",
+		From:     "Bug bot <bot@example.com>",
+		To:       []string{"ops@example.com", "vendor@example.com"},
+	}
+	sm, err := NewSMTPSender(cfg)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	text := `{"text":"Уровень: ERROR\n","html":"<b>ERROR</b>"}`
+	if err := sm.Send(context.Background(), Message{Text: text, Entry: testSMTPEntry()}); err != nil {
+		t.Fatalf("ожидается успешная отправка, получено: %v", err)
+	}
+	<-srv.done
+
+	if strings.Join(srv.auth, ",") != "LOGIN,bot,secret" {
+		t.Fatalf("неверная авторизация: %v", srv.auth)
+	}
+	if srv.from != "MAIL FROM:<bot@example.com>" || len(srv.rcpts) != 2 {
+		t.Fatalf("неверные отправитель или получатели: %q %v", srv.from, srv.rcpts)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(srv.data))
+	if err != nil {
+		t.Fatalf("письмо должно разбираться: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if subject != "[ERROR] payments: оплата не прошла" {
+		t.Fatalf("неверная тема: %q", subject)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("ожидается multipart/alternative, получено %q", mediaType)
+	}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := mr.NextPart() // quoted-printable декодируется автоматически
+		if err != nil {
+			break
+		}
+		b, _ := io.ReadAll(p)
+		parts = append(parts, p.Header.Get("Content-Type")+"|"+string(b))
+	}
+	if len(parts) != 2 || parts[0] != "text/plain; charset=utf-8|Уровень: ERROR\n" || parts[1] != "text/html; charset=utf-8|<b>ERROR</b>" {
+		t.Fatalf("неверные части письма: %q", parts)
+	}
+}
+
+func TestSMTPSender_ImplicitTLSAndPlainAuth(t *testing.T) {
+	// сертификат берём у httptest, чтобы не генерировать свой
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+	srv := newFakeSMTP(t, &tls.Config{Certificates: ts.TLS.Certificates})
+
+	sm, err := NewSMTPSender(config.SMTPConfig{
+		Host: "127.0.0.1", Port: srv.port(), TLS: "tls", Auth: "plain",
+		Username: "bot", Password: "secret", InsecureSkipVerify: true,
+		From: "bot@example.com", To: []string{"ops@example.com"},
+		SubjectTemplate: "{{upper .Level}} {{.Fields.order_id}}",
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	entry := testSMTPEntry()
+	entry.Fields = map[string]string{"order_id": "123"}
+	if err := sm.Send(context.Background(), Message{Text: "простой текст", Entry: entry}); err != nil {
+		t.Fatalf("ожидается успешная отправка, получено: %v", err)
+	}
+	<-srv.done
+
+	if len(srv.auth) != 2 || srv.auth[1] != "\x00bot\x00secret" {
+		t.Fatalf("неверная авторизация PLAIN: %q", srv.auth)
+	}
+	if !strings.Contains(srv.data, "Subject: ERROR 123\n") {
+		t.Fatalf("неверная тема письма:\n%s", srv.data)
+	}
+}
+
+func TestSMTPSender_TemporaryRejectIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		code      int
+		retryable bool
+	}{{451, true}, {550, false}} {
+		t.Run(strconv.Itoa(tc.code), func(t *testing.T) {
+			srv := newFakeSMTP(t, nil)
+			srv.rcptCode = tc.code
+
+			sm, _ := NewSMTPSender(config.SMTPConfig{
+				Host: "127.0.0.1", Port: srv.port(), TLS: "none", Auth: "none",
+				From: "bot@example.com", To: []string{"ops@example.com"},
+			})
+			err := sm.Send(context.Background(), Message{Text: "x"})
+
+			var tpErr *textproto.Error
+			if !errors.As(err, &tpErr) || tpErr.Code != tc.code {
+				t.Fatalf("ожидается ошибка SMTP %d, получено: %v", tc.code, err)
+			}
+			if IsRetryable(err) != tc.retryable {
+				t.Fatalf("код %d: повтор ожидается=%v", tc.code, tc.retryable)
+			}
+		})
+	}
+}
+
@@ -7,9 +7,9 @@ import (
 
 type StdoutSender struct{}
 
-func (s *StdoutSender) Send(_ context.Context, text string) error {
+func (s *StdoutSender) Send(_ context.Context, msg Message) error {
 	fmt.Println("================================")
-	fmt.Println(text)
+	fmt.Println(msg.Text)
 	fmt.Println("================================")
 	return nil
 }
@@ -1,84 +1,331 @@
 package sender
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"net/http"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+)
+
+const (
+	// maxPendingDeliveries сколько частично доставленных сообщений помнить между повторами
+	maxPendingDeliveries = 1000
+	// telegramSummaryLimit длина начала сообщения, которое отправляется вместе с вложением
+	telegramSummaryLimit = 1000
+	// defaultDocumentThreshold как telegram.document_threshold по умолчанию, для личных сообщений
+	defaultDocumentThreshold = 12000
 )
 
 type TelegramSender struct {
-	token   string
-	chatID  string
-	client  *http.Client
-	baseURL string
+	*TelegramClient
+	chats             []telegramChat
+	documentThreshold int  // длиннее - краткое начало и вложение .txt; <= 0 - всегда резать на части
+	buttons           bool // кнопки действий под алертом
+	liveUpdates       bool // запоминать сообщения алертов и дописывать в них повторы
+
+	// delivered сколько шагов (частей сообщения) уже ушло в каждый чат: при повторе после ошибки
+	// ни другие чаты, ни уже отправленные части не получают дубликат
+	mu        sync.Mutex
+	delivered map[string]map[int]int
+}
+
+// telegramStep одно сообщение или одно вложение; длинный алерт отправляется несколькими шагами
+type telegramStep struct {
+	text     string
+	document []byte
+	filename string
+	keyboard *InlineKeyboard
+	live     bool // это сообщение обновляется при повторах и нажатиях кнопок
+}
+
+// telegramChat чат или тема форума с условиями отбора алертов
+type telegramChat struct {
+	chatID   string
+	threadID int
+	levels   []string
+	rules    []string
+	silent   []string
 }
 
 func NewTelegramSender(cfg config.TelegramConfig) (*TelegramSender, error) {
-	if cfg.BotToken == "" || cfg.ChatID == "" {
+	if cfg.BotToken == "" || (cfg.ChatID == "" && len(cfg.Chats) == 0) {
 		return nil, fmt.Errorf("отсутствует токен бота или ChatId")
 	}
 
+	var chats []telegramChat
+	if cfg.ChatID != "" {
+		chats = append(chats, telegramChat{chatID: cfg.ChatID, threadID: cfg.ThreadID, silent: cfg.SilentLevels})
+	}
+	for _, ch := range cfg.Chats {
+		chats = append(chats, telegramChat{
+			chatID:   ch.ChatID,
+			threadID: ch.ThreadID,
+			levels:   ch.Levels,
+			rules:    ch.Rules,
+			silent:   ch.SilentLevels,
+		})
+	}
+
 	return &TelegramSender{
-		token:   cfg.BotToken,
-		chatID:  cfg.ChatID,
-		client:  &http.Client{Timeout: 5 * time.Second},
-		baseURL: "https://api.telegram.org",
+		TelegramClient:    NewTelegramClient(cfg.BotToken, 5*time.Second),
+		chats:             chats,
+		documentThreshold: cfg.DocumentThreshold,
+		buttons:           cfg.Buttons,
+		liveUpdates:       cfg.LiveUpdates,
+		delivered:         make(map[string]map[int]int),
 	}, nil
 }
 
+// NewTelegramDirectSender отправитель личных сообщений: чаты берутся не из конфига, а из Message.Recipients
+func NewTelegramDirectSender(token string) *TelegramSender {
+	return &TelegramSender{
+		TelegramClient:    NewTelegramClient(token, 5*time.Second),
+		documentThreshold: defaultDocumentThreshold,
+		delivered:         make(map[string]map[int]int),
+	}
+}
+
 type telegramSendMessageRequest struct {
 	ChatID                string `json:"chat_id"`
+	MessageThreadID       int    `json:"message_thread_id,omitempty"` // тема форума
 	Text                  string `json:"text"`
 	ParseMode             string `json:"parse_mode,omitempty"` // для HTML
 	DisableWebPagePreview bool   `json:"disable_web_page_preview,omitempty"`
+	DisableNotification   bool   `json:"disable_notification,omitempty"`
+
+	ReplyMarkup *InlineKeyboard `json:"reply_markup,omitempty"`
 }
 
-type telegramSendMessageResponse struct {
-	OK          bool   `json:"ok"`
-	Description string `json:"description"`
+func (s *TelegramSender) Send(ctx context.Context, msg Message) error {
+	if msg.Event == EventRepeat {
+		return s.sendRepeat(ctx, msg)
+	}
+
+	steps := s.plan(msg)
+	key := deliveryKey(msg)
+	s.mu.Lock()
+	done := maps.Clone(s.delivered[key])
+	s.mu.Unlock()
+
+	var retryErr, lastErr error
+	matched, ok := 0, 0
+	for i, ch := range s.chatsFor(msg) {
+		if !ch.accepts(msg) {
+			continue
+		}
+		matched++
+		start := done[i]
+		if start >= len(steps) {
+			ok++
+			continue
+		}
+
+		n, err := s.deliver(ctx, ch, msg, steps[start:])
+		switch {
+		case err == nil:
+			ok++
+			s.markDelivered(key, i, len(steps))
+		case IsRetryable(err):
+			retryErr = err
+			s.markDelivered(key, i, start+n)
+		default:
+			// например "chat not found": повтор не поможет, в остальные чаты доставляем
+			log.Printf("Ошибка отправки в чат %s: %v", ch, err)
+			lastErr = err
+			s.markDelivered(key, i, len(steps))
+		}
+	}
+
+	if retryErr != nil {
+		return retryErr
+	}
+	s.forget(key)
+
+	if matched > 0 && ok == 0 {
+		return lastErr
+	}
+	return nil
 }
 
-func (s *TelegramSender) Send(ctx context.Context, text string) error {
-	body := telegramSendMessageRequest{
-		ChatID:                s.chatID,
-		Text:                  text,
-		ParseMode:             "HTML",
-		DisableWebPagePreview: true,
+// plan разбивает сообщение на шаги: одно сообщение, несколько частей до 4096 символов
+// или, если текст длиннее document_threshold, краткое начало и полный текст во вложении
+func (s *TelegramSender) plan(msg Message) []telegramStep {
+	steps := s.split(msg)
+	if msg.Entry == nil {
+		return steps
+	}
+	// кнопки и счётчик повторов - в последнем тексте, рядом с концом алерта
+	for i := len(steps) - 1; i >= 0; i-- {
+		if steps[i].document != nil {
+			continue
+		}
+		if s.buttons {
+			steps[i].keyboard = AlertKeyboard(*msg.Entry)
+		}
+		steps[i].live = s.liveUpdates && msg.Entry.Fingerprint != ""
+		break
+	}
+	return steps
+}
+
+func (s *TelegramSender) split(msg Message) []telegramStep {
+	n := utf16Len(msg.Text)
+	if n <= telegramMessageLimit {
+		return []telegramStep{{text: msg.Text}}
 	}
 
-	b, err := json.Marshal(body)
-	if err != nil {
-		return fmt.Errorf("ошибка кодирования в формат JSON: %w", err)
+	if s.documentThreshold > 0 && n > s.documentThreshold {
+		summary := splitHTML(msg.Text, telegramSummaryLimit)[0] +
+			"\n\n<i>Сообщение слишком длинное, полный текст во вложении</i>"
+		name := "alert.txt"
+		if msg.Entry != nil && msg.Entry.Fingerprint != "" {
+			name = "alert-" + msg.Entry.Fingerprint + ".txt"
+		}
+		return []telegramStep{
+			{text: summary},
+			{document: []byte(htmlToText(msg.Text)), filename: name},
+		}
 	}
 
-	url := fmt.Sprintf("%s/bot%s/sendMessage", s.baseURL, s.token)
+	parts := splitHTML(msg.Text, telegramMessageLimit)
+	steps := make([]telegramStep, len(parts))
+	for i, p := range parts {
+		steps[i] = telegramStep{text: p}
+	}
+	return steps
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
-	if err != nil {
-		return fmt.Errorf("ошибка при создании запроса telegram: %w", err)
+// deliver отправляет шаги по порядку в один чат и возвращает, сколько из них успешно ушло
+func (s *TelegramSender) deliver(ctx context.Context, ch telegramChat, msg Message, steps []telegramStep) (int, error) {
+	silent := ch.silentFor(msg)
+	for i, st := range steps {
+		var err error
+		if st.document != nil {
+			err = s.sendDocument(ctx, ch, st.filename, st.document, silent)
+		} else {
+			err = s.sendMessage(ctx, ch, st, silent, msg.Entry)
+		}
+		if err != nil {
+			return i, err
+		}
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return len(steps), nil
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("не удалось выполнить запрос в telegram: %w", err)
+// chatsFor чаты сообщения: получатели из Recipients или чаты из конфига
+func (s *TelegramSender) chatsFor(msg Message) []telegramChat {
+	if len(msg.Recipients) == 0 {
+		return s.chats
 	}
-	defer resp.Body.Close()
+	chats := make([]telegramChat, len(msg.Recipients))
+	for i, id := range msg.Recipients {
+		chats[i] = telegramChat{chatID: id}
+	}
+	return chats
+}
 
-	// Читаем ответ telegram
-	var tgResp telegramSendMessageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tgResp); err != nil {
-		return fmt.Errorf("декодируем ответ telegram: %w", err)
+// accepts подходит ли сообщение чату; служебные сообщения уходят во все чаты
+func (ch telegramChat) accepts(msg Message) bool {
+	if msg.Entry == nil {
+		return true
+	}
+	if len(ch.levels) > 0 && !slices.Contains(ch.levels, msg.Entry.Level) {
+		return false
+	}
+	if len(ch.rules) > 0 && !slices.Contains(ch.rules, msg.Entry.Rule) {
+		return false
 	}
+	return true
+}
+
+func (ch telegramChat) silentFor(msg Message) bool {
+	return msg.Entry != nil && slices.Contains(ch.silent, msg.Entry.Level)
+}
 
-	if resp.StatusCode != http.StatusOK || !tgResp.OK {
-		return fmt.Errorf("ошибка api telegram: статус=%d ок=%v описание=%s", resp.StatusCode, tgResp.OK, tgResp.Description)
+func (s *TelegramSender) sendMessage(ctx context.Context, ch telegramChat, st telegramStep, silent bool, entry *log_processing.LogEntry) error {
+	var sent telegramSentMessage
+	err := s.Call(ctx, "sendMessage", telegramSendMessageRequest{
+		ChatID:                ch.chatID,
+		MessageThreadID:       ch.threadID,
+		Text:                  st.text,
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+		DisableNotification:   silent,
+		ReplyMarkup:           st.keyboard,
+	}, &sent)
+	if err == nil && st.live {
+		live.add(s.fpKey(*entry), &liveMessage{
+			chatID:    sent.Chat.ID,
+			messageID: sent.MessageID,
+			text:      st.text,
+			keyboard:  st.keyboard,
+		})
 	}
+	return err
+}
 
-	return nil
+// telegramSentMessage часть ответа sendMessage, нужная для редактирования сообщения
+type telegramSentMessage struct {
+	MessageID int `json:"message_id"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+// sendDocument загружает файл через multipart/form-data
+func (s *TelegramSender) sendDocument(ctx context.Context, ch telegramChat, filename string, content []byte, silent bool) error {
+	fields := map[string]string{"chat_id": ch.chatID}
+	if ch.threadID != 0 {
+		fields["message_thread_id"] = strconv.Itoa(ch.threadID)
+	}
+	if silent {
+		fields["disable_notification"] = "true"
+	}
+	return s.Upload(ctx, "sendDocument", fields, "document", filename, content, nil)
+}
+
+// deliveryKey ключ сообщения для учёта частичной доставки между повторами
+func deliveryKey(msg Message) string {
+	h := sha256.New()
+	h.Write([]byte(msg.Text))
+	if msg.Entry != nil {
+		h.Write([]byte(msg.Entry.Fingerprint + "\x00" + msg.Entry.Timestamp.String() + "\x00" + msg.Entry.Raw))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func (s *TelegramSender) markDelivered(key string, chat, steps int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.delivered[key] == nil {
+		if len(s.delivered) >= maxPendingDeliveries {
+			// память ограничена: в худшем случае при повторе будет дубликат
+			clear(s.delivered)
+		}
+		s.delivered[key] = make(map[int]int)
+	}
+	s.delivered[key][chat] = steps
+}
+
+func (s *TelegramSender) forget(key string) {
+	s.mu.Lock()
+	delete(s.delivered, key)
+	s.mu.Unlock()
+}
+
+// String подпись чата для логов: id и тема форума
+func (ch telegramChat) String() string {
+	if ch.threadID != 0 {
+		return ch.chatID + "/" + strconv.Itoa(ch.threadID)
+	}
+	return ch.chatID
 }
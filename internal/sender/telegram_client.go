@@ -0,0 +1,126 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// TelegramClient вызовы Bot API; общий для отправителя алертов и интерактивного бота
+type TelegramClient struct {
+	token   string
+	client  *http.Client
+	baseURL string
+}
+
+// NewTelegramClient timeout - на один запрос; для long polling нужен больше таймаута getUpdates
+func NewTelegramClient(token string, timeout time.Duration) *TelegramClient {
+	return &TelegramClient{
+		token:   token,
+		client:  &http.Client{Timeout: timeout},
+		baseURL: "https://api.telegram.org",
+	}
+}
+
+// SetBaseURL адрес Bot API, для тестов с локальной заглушкой
+func (c *TelegramClient) SetBaseURL(u string) {
+	c.baseURL = u
+}
+
+// telegramResponse общий ответ Bot API; result зависит от метода
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"` // секунды, приходит вместе с 429
+	} `json:"parameters"`
+}
+
+// Call вызывает метод Bot API с JSON-телом; result ответа декодируется в out, если out не nil
+func (c *TelegramClient) Call(ctx context.Context, method string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования в формат JSON: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.methodURL(method), bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса telegram: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.do(req, out)
+}
+
+func (c *TelegramClient) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+}
+
+// do выполняет запрос к Bot API и разбирает ответ
+func (c *TelegramClient) do(req *http.Request, out any) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось выполнить запрос в telegram: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Читаем ответ telegram
+	var tgResp telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tgResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			// например 502 от прокси с HTML вместо JSON
+			return fmt.Errorf("ошибка api telegram: %w", &APIError{StatusCode: resp.StatusCode, Description: http.StatusText(resp.StatusCode)})
+		}
+		return fmt.Errorf("декодируем ответ telegram: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || !tgResp.OK {
+		return fmt.Errorf("ошибка api telegram: %w", &APIError{
+			StatusCode:  resp.StatusCode,
+			Description: tgResp.Description,
+			RetryAfter:  time.Duration(tgResp.Parameters.RetryAfter) * time.Second,
+		})
+	}
+
+	if out != nil && len(tgResp.Result) > 0 {
+		if err := json.Unmarshal(tgResp.Result, out); err != nil {
+			return fmt.Errorf("декодируем ответ telegram: %w", err)
+		}
+	}
+	return nil
+}
+
+// Upload вызывает метод Bot API с multipart/form-data: поля fields и файл в поле field
+func (c *TelegramClient) Upload(ctx context.Context, method string, fields map[string]string, field, filename string, content []byte, out any) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return fmt.Errorf("ошибка формирования вложения: %w", err)
+		}
+	}
+	fw, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		return fmt.Errorf("ошибка формирования вложения: %w", err)
+	}
+	if _, err := fw.Write(content); err != nil {
+		return fmt.Errorf("ошибка формирования вложения: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("ошибка формирования вложения: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.methodURL(method), &body)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса telegram: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	return c.do(req, out)
+}
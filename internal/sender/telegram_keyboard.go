@@ -0,0 +1,76 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Кнопки под алертом в Telegram. Нажатие приходит боту как callback_query с data вида "<действие>:<аргумент>";
+// data ограничена 64 байтами, поэтому в ней только короткие идентификаторы.
+
+const (
+	CallbackAck  = "ack"  // аргумент - AlertID
+	CallbackMute = "mute" // аргумент - StableKey алерта
+	CallbackRaw  = "raw"  // аргумент - AlertID
+)
+
+// InlineKeyboard reply_markup сообщения
+type InlineKeyboard struct {
+	InlineKeyboard [][]InlineButton `json:"inline_keyboard"`
+}
+
+type InlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+// AlertID идентификатор конкретного алерта (в отличие от fingerprint, разный у повторов)
+func AlertID(entry log_processing.LogEntry) string {
+	sum := sha256.Sum256([]byte(entry.Fingerprint + "\x00" + entry.Timestamp.String() + "\x00" + entry.Raw))
+	return hex.EncodeToString(sum[:8])
+}
+
+// AlertKeyboard кнопки для алерта
+func AlertKeyboard(entry log_processing.LogEntry) *InlineKeyboard {
+	id := AlertID(entry)
+	row := []InlineButton{
+		{Text: "✅ Принял", CallbackData: CallbackAck + ":" + id},
+	}
+	if entry.Fingerprint != "" {
+		// без group_by fingerprint уникален для каждой строки, поэтому глушим по стабильному ключу
+		row = append(row, InlineButton{Text: "🔕 Mute 1h", CallbackData: CallbackMute + ":" + protect_from_duplicates.StableKey(entry)})
+	}
+	row = append(row, InlineButton{Text: "📄 Исходная строка", CallbackData: CallbackRaw + ":" + id})
+	return &InlineKeyboard{InlineKeyboard: [][]InlineButton{row}}
+}
+
+// ParseCallback разбирает data кнопки на действие и аргумент
+func ParseCallback(data string) (action, arg string, ok bool) {
+	return strings.Cut(data, ":")
+}
+
+// Without клавиатура без кнопок с действием action; nil, если кнопок не осталось
+func (k *InlineKeyboard) Without(action string) *InlineKeyboard {
+	if k == nil {
+		return nil
+	}
+	out := &InlineKeyboard{}
+	for _, row := range k.InlineKeyboard {
+		var kept []InlineButton
+		for _, b := range row {
+			if a, _, _ := ParseCallback(b.CallbackData); a != action {
+				kept = append(kept, b)
+			}
+		}
+		if len(kept) > 0 {
+			out.InlineKeyboard = append(out.InlineKeyboard, kept)
+		}
+	}
+	if len(out.InlineKeyboard) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Живые сообщения: TelegramSender запоминает message_id отправленного алерта, чтобы дописывать в него
+// счётчик повторов (EventRepeat), а бот - отметки кнопок. Реестр общий на пакет: отправители
+// пересоздаются при hot reload, а бот живёт отдельно от них.
+
+// maxLiveFingerprints сколько алертов можно обновлять; старые забываются, повторы по ним не показываются
+const maxLiveFingerprints = 1000
+
+type liveMessage struct {
+	chatID    int64
+	messageID int
+	text      string // HTML, как был отправлен
+	keyboard  *InlineKeyboard
+	repeat    string   // строка о повторах, HTML
+	status    []string // отметки кнопок, HTML
+}
+
+// render текст сообщения со строкой повторов и отметками
+func (m *liveMessage) render() string {
+	var sb strings.Builder
+	sb.WriteString(m.text)
+	if m.repeat != "" {
+		sb.WriteString("\n\n" + m.repeat)
+	}
+	for _, st := range m.status {
+		sb.WriteString("\n\n" + st)
+	}
+	return sb.String()
+}
+
+type liveRegistry struct {
+	mu    sync.Mutex
+	byFP  map[string][]*liveMessage // по боту и StableKey алерта (fpKey): по одному сообщению на чат
+	byMsg map[string]*liveMessage   // по chat_id:message_id
+}
+
+var live = &liveRegistry{
+	byFP:  make(map[string][]*liveMessage),
+	byMsg: make(map[string]*liveMessage),
+}
+
+func liveKey(chatID int64, messageID int) string {
+	return strconv.FormatInt(chatID, 10) + ":" + strconv.Itoa(messageID)
+}
+
+// fpKey ключ алерта с учётом бота: чужие сообщения бот редактировать не может.
+// Берётся StableKey, а не Fingerprint: без group_by у повтора с другим временем другой Fingerprint.
+func (s *TelegramSender) fpKey(entry log_processing.LogEntry) string {
+	return s.token + "\x00" + protect_from_duplicates.StableKey(entry)
+}
+
+// add запоминает новое сообщение алерта; прежнее сообщение в том же чате больше не обновляется
+func (r *liveRegistry) add(fp string, m *liveMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byFP[fp]; !ok && len(r.byFP) >= maxLiveFingerprints {
+		// память ограничена: повторы старых алертов просто не будут дописаны
+		clear(r.byFP)
+		clear(r.byMsg)
+	}
+
+	msgs := r.byFP[fp][:0:0]
+	for _, old := range r.byFP[fp] {
+		if old.chatID == m.chatID {
+			delete(r.byMsg, liveKey(old.chatID, old.messageID))
+			continue
+		}
+		msgs = append(msgs, old)
+	}
+	r.byFP[fp] = append(msgs, m)
+	r.byMsg[liveKey(m.chatID, m.messageID)] = m
+}
+
+// setRepeat обновляет строку повторов и возвращает новые тексты сообщений fingerprint
+func (r *liveRegistry) setRepeat(fp, line string) []liveEdit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var edits []liveEdit
+	for _, m := range r.byFP[fp] {
+		m.repeat = line
+		edits = append(edits, liveEdit{chatID: m.chatID, messageID: m.messageID, text: m.render(), keyboard: m.keyboard})
+	}
+	return edits
+}
+
+type liveEdit struct {
+	chatID    int64
+	messageID int
+	text      string
+	keyboard  *InlineKeyboard
+}
+
+// MarkLiveMessage запоминает отметку, которую бот дописал в сообщение алерта (status - обычный текст),
+// и оставшиеся кнопки, чтобы следующее обновление счётчика повторов их не стёрло
+func MarkLiveMessage(chatID int64, messageID int, status string, keyboard *InlineKeyboard) {
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	if m, ok := live.byMsg[liveKey(chatID, messageID)]; ok {
+		m.status = append(m.status, html.EscapeString(status))
+		m.keyboard = keyboard
+	}
+}
+
+// repeatLine строка о повторах в сообщении
+func repeatLine(r Repeat) string {
+	return fmt.Sprintf("🔁 <b>Повторялось %d %s</b>, последний раз в %s", r.Count, times(r.Count), r.LastSeen.Format("15:04"))
+}
+
+// times «раз» или «раза» после числа
+func times(n int) string {
+	if n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) {
+		return "раза"
+	}
+	return "раз"
+}
+
+// sendRepeat дописывает счётчик повторов во все отправленные сообщения алерта.
+// Если сообщения нет (бот перезапускался или алерт давно вытеснен), повтор не показывается.
+func (s *TelegramSender) sendRepeat(ctx context.Context, msg Message) error {
+	if msg.Entry == nil || msg.Repeat == nil || !s.liveUpdates {
+		return nil
+	}
+
+	var retryErr error
+	for _, e := range live.setRepeat(s.fpKey(*msg.Entry), repeatLine(*msg.Repeat)) {
+		req := map[string]any{
+			"chat_id":                  e.chatID,
+			"message_id":               e.messageID,
+			"text":                     e.text,
+			"parse_mode":               "HTML",
+			"disable_web_page_preview": true,
+		}
+		if e.keyboard != nil {
+			// без reply_markup Telegram убрал бы кнопки
+			req["reply_markup"] = e.keyboard
+		}
+		err := s.Call(ctx, "editMessageText", req, nil)
+
+		var apiErr *APIError
+		switch {
+		case err == nil:
+		case IsRetryable(err):
+			retryErr = err
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && strings.Contains(apiErr.Description, "not modified"):
+			// тот же текст: счётчик не изменился с прошлого обновления
+		default:
+			// сообщение удалено, слишком длинное и т.п.: повторять бессмысленно
+			log.Printf("Ошибка обновления сообщения %d в чате %d: %v", e.messageID, e.chatID, err)
+		}
+	}
+	return retryErr
+}
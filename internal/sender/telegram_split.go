@@ -0,0 +1,161 @@
+package sender
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// telegramMessageLimit Telegram считает длину в UTF-16 символах
+const telegramMessageLimit = 4096
+
+// htmlToken тег, HTML-сущность или один символ текста; резать сообщение можно только между токенами
+type htmlToken struct {
+	s     string
+	open  string // имя тега, если токен открывает тег
+	close string // имя тега, если токен закрывает тег
+}
+
+func tokenizeHTML(s string) []htmlToken {
+	var toks []htmlToken
+	for len(s) > 0 {
+		switch s[0] {
+		case '<':
+			if end := strings.IndexByte(s, '>'); end > 0 {
+				tag := s[:end+1]
+				tok := htmlToken{s: tag}
+				name := strings.TrimPrefix(tag[1:end], "/")
+				if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+					name = name[:i]
+				}
+				name = strings.ToLower(name)
+				if strings.HasPrefix(tag, "</") {
+					tok.close = name
+				} else if !strings.HasSuffix(tag, "/>") {
+					tok.open = name
+				}
+				toks = append(toks, tok)
+				s = s[end+1:]
+				continue
+			}
+		case '&':
+			if end := strings.IndexByte(s, ';'); end > 0 && end <= 10 {
+				toks = append(toks, htmlToken{s: s[:end+1]})
+				s = s[end+1:]
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		toks = append(toks, htmlToken{s: s[:size]})
+		s = s[size:]
+	}
+	return toks
+}
+
+// utf16Len длина строки так, как её считает Telegram
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+type openTag struct {
+	name string
+	raw  string // исходный открывающий тег с атрибутами, чтобы повторить его в следующей части
+}
+
+func closingTags(stack []openTag) string {
+	var b strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteString("</" + stack[i].name + ">")
+	}
+	return b.String()
+}
+
+func openingTags(stack []openTag) string {
+	var b strings.Builder
+	for _, t := range stack {
+		b.WriteString(t.raw)
+	}
+	return b.String()
+}
+
+// splitHTML режет HTML-сообщение на части не длиннее limit, не разрывая теги и сущности.
+// Режем по возможности по переводу строки; теги, открытые на месте разреза,
+// закрываются в конце части и открываются заново в начале следующей.
+func splitHTML(s string, limit int) []string {
+	if utf16Len(s) <= limit {
+		return []string{s}
+	}
+
+	var (
+		parts  []string
+		stack  []openTag
+		chunk  strings.Builder
+		length int // длина chunk в UTF-16
+		prefix int // длина повторно открытых тегов в начале chunk, в байтах
+
+		// последний перевод строки в chunk: где резать и какие теги были открыты
+		breakPos   = -1
+		breakStack []openTag
+	)
+
+	flush := func() {
+		cut, st := chunk.Len(), stack
+		if breakPos > prefix {
+			cut, st = breakPos, breakStack
+		}
+		text := chunk.String()
+		parts = append(parts, text[:cut]+closingTags(st))
+
+		rest := openingTags(st) + text[cut:]
+		prefix = len(openingTags(st))
+		chunk.Reset()
+		chunk.WriteString(rest)
+		length = utf16Len(rest)
+		breakPos, breakStack = -1, nil
+	}
+
+	for _, tok := range tokenizeHTML(s) {
+		need := utf16Len(tok.s) + utf16Len(closingTags(stack))
+		if tok.open != "" {
+			need += len("</" + tok.open + ">")
+		}
+		if length+need > limit && chunk.Len() > prefix {
+			flush()
+		}
+
+		chunk.WriteString(tok.s)
+		length += utf16Len(tok.s)
+
+		switch {
+		case tok.open != "":
+			stack = append(stack, openTag{name: tok.open, raw: tok.s})
+		case tok.close != "":
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == tok.close {
+					stack = append(stack[:i:i], stack[i+1:]...)
+					break
+				}
+			}
+		case tok.s == "\n":
+			breakPos = chunk.Len()
+			breakStack = append([]openTag(nil), stack...)
+		}
+	}
+	if strings.TrimSpace(chunk.String()[prefix:]) != "" {
+		parts = append(parts, chunk.String()+closingTags(stack))
+	}
+	return parts
+}
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// htmlToText текст без разметки для вложения .txt
+func htmlToText(s string) string {
+	return html.UnescapeString(htmlTagRe.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,88 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSplitHTML_KeepsTagsAndEntitiesBalanced(t *testing.T) {
+	raw := strings.Repeat("строка &lt;stack&gt; &amp; trace 🔴\n", 300)
+	text := "<b>Уровень</b> ERROR\n<b>Исходный лог:</b>\n<code>" + raw + "</code>\n"
+
+	parts := splitHTML(text, 1000)
+	if len(parts) < 2 {
+		t.Fatalf("ожидается несколько частей, получено %d", len(parts))
+	}
+
+	var joined strings.Builder
+	for i, p := range parts {
+		if n := utf16Len(p); n > 1000 {
+			t.Fatalf("часть %d длиннее лимита: %d", i, n)
+		}
+		if strings.Count(p, "<code>") != strings.Count(p, "</code>") {
+			t.Fatalf("часть %d: несбалансированные теги:\n%s", i, p)
+		}
+		for _, tok := range tokenizeHTML(p) {
+			if strings.HasPrefix(tok.s, "&") && !strings.HasSuffix(tok.s, ";") {
+				t.Fatalf("часть %d: разорванная сущность %q", i, tok.s)
+			}
+		}
+		joined.WriteString(htmlToText(p))
+	}
+	if joined.String() != htmlToText(text) {
+		t.Fatal("после склейки частей текст должен совпадать с исходным")
+	}
+	// режем по переводам строк, поэтому каждая часть, кроме последней, заканчивается целой строкой
+	if !strings.HasSuffix(parts[0], "\n</code>") {
+		t.Fatalf("ожидается разрез по переводу строки, получено окончание %q", parts[0][len(parts[0])-30:])
+	}
+}
+
+func TestTelegramSender_LongMessageAsDocument(t *testing.T) {
+	var methods []string
+	var document, summary string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		methods = append(methods, method)
+		if method == "sendDocument" {
+			f, h, err := r.FormFile("document")
+			if err != nil || r.FormValue("chat_id") != "1" || h.Filename != "alert-fp.txt" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"ok":false,"description":"bad document"}`))
+				return
+			}
+			b, _ := io.ReadAll(f)
+			document = string(b)
+		} else {
+			b, _ := io.ReadAll(r.Body)
+			summary = string(b)
+		}
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer srv.Close()
+
+	tg := newTestTelegram(t, nil)
+	tg.baseURL = srv.URL
+	tg.documentThreshold = 5000
+
+	text := "<b>ERROR</b>\n<code>" + strings.Repeat("x &amp; y\n", 1000) + "</code>"
+	msg := Message{Text: text, Entry: &log_processing.LogEntry{Level: "ERROR", Fingerprint: "fp"}}
+	if err := tg.Send(context.Background(), msg); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+
+	if strings.Join(methods, ",") != "sendMessage,sendDocument" {
+		t.Fatalf("ожидается краткое сообщение и вложение, получено %v", methods)
+	}
+	if !strings.Contains(summary, "полный текст во вложении") {
+		t.Fatalf("краткое сообщение без пометки о вложении: %s", summary)
+	}
+	if !strings.HasPrefix(document, "ERROR\nx & y\n") || strings.Contains(document, "<code>") {
+		t.Fatalf("вложение должно быть текстом без разметки: %q", document[:40])
+	}
+}
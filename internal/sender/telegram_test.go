@@ -0,0 +1,240 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTelegram принимает sendMessage и запоминает запросы; failChat отвечает 502
+type fakeTelegram struct {
+	mu       sync.Mutex
+	requests []telegramSendMessageRequest
+	failChat string
+}
+
+func (f *fakeTelegram) server(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req telegramSendMessageRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if req.ChatID == f.failChat {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		f.requests = append(f.requests, req)
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func multiChatConfig() config.TelegramConfig {
+	return config.TelegramConfig{
+		BotToken:     "token",
+		ChatID:       "main",
+		ThreadID:     7,
+		SilentLevels: []string{"DEBUG", "INFO"},
+		Chats: []config.TelegramChatConfig{
+			{ChatID: "payments", Rules: []string{"payments"}, SilentLevels: []string{}},
+			{ChatID: "errors", Levels: []string{"ERROR"}, SilentLevels: []string{"DEBUG", "INFO"}},
+		},
+	}
+}
+
+func TestTelegramSender_ChatsTopicsAndSilentLevels(t *testing.T) {
+	fake := &fakeTelegram{}
+	tg, err := NewTelegramSender(multiChatConfig())
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	tg.baseURL = fake.server(t).URL
+
+	info := &log_processing.LogEntry{Level: "INFO", Rule: "payments"}
+	if err := tg.Send(context.Background(), Message{Text: "info", Entry: info}); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+
+	got := map[string]telegramSendMessageRequest{}
+	for _, r := range fake.requests {
+		got[r.ChatID] = r
+	}
+	if len(fake.requests) != 2 {
+		t.Fatalf("INFO по правилу payments: ожидаются чаты main и payments, получено %+v", fake.requests)
+	}
+	if got["main"].MessageThreadID != 7 || !got["main"].DisableNotification {
+		t.Fatalf("main: ожидается тема 7 и отправка без звука, получено %+v", got["main"])
+	}
+	if got["payments"].DisableNotification {
+		t.Fatal("payments: silent_levels=[] - уведомление должно быть со звуком")
+	}
+}
+
+func TestTelegramSender_RetryDoesNotDuplicateDeliveredChats(t *testing.T) {
+	fake := &fakeTelegram{failChat: "errors"}
+	tg, _ := NewTelegramSender(multiChatConfig())
+	tg.baseURL = fake.server(t).URL
+
+	msg := Message{Text: "boom", Entry: &log_processing.LogEntry{Level: "ERROR", Rule: "db"}}
+	err := tg.Send(context.Background(), msg)
+	if err == nil || !IsRetryable(err) {
+		t.Fatalf("ожидается временная ошибка из чата errors, получено: %v", err)
+	}
+
+	fake.failChat = ""
+	if err := tg.Send(context.Background(), msg); err != nil {
+		t.Fatalf("повтор: %v", err)
+	}
+
+	count := map[string]int{}
+	for _, r := range fake.requests {
+		count[r.ChatID]++
+		if r.DisableNotification {
+			t.Fatalf("ERROR должен приходить со звуком: %+v", r)
+		}
+	}
+	if count["main"] != 1 || count["errors"] != 1 || count["payments"] != 0 {
+		t.Fatalf("каждый подходящий чат должен получить сообщение ровно один раз, получено %v", count)
+	}
+}
+
+func TestTelegramSender_Buttons(t *testing.T) {
+	fake := &fakeTelegram{}
+	tg, _ := NewTelegramSender(config.TelegramConfig{BotToken: "token", ChatID: "main", Buttons: true})
+	tg.baseURL = fake.server(t).URL
+
+	entry := &log_processing.LogEntry{Level: "ERROR", Fingerprint: "abc123", Raw: "line"}
+	if err := tg.Send(context.Background(), Message{Text: "boom", Entry: entry}); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+	if err := tg.Send(context.Background(), Message{Text: "сводка"}); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+
+	kb := fake.requests[0].ReplyMarkup
+	if kb == nil || len(kb.InlineKeyboard[0]) != 3 {
+		t.Fatalf("ожидаются три кнопки под алертом, получено %+v", kb)
+	}
+	id := AlertID(*entry)
+	want := []string{"ack:" + id, "mute:abc123", "raw:" + id}
+	for i, b := range kb.InlineKeyboard[0] {
+		if b.CallbackData != want[i] {
+			t.Fatalf("кнопка %d: ожидается %q, получено %q", i, want[i], b.CallbackData)
+		}
+	}
+	if fake.requests[1].ReplyMarkup != nil {
+		t.Fatal("у служебных сообщений кнопок быть не должно")
+	}
+
+	if left := kb.Without(CallbackAck); len(left.InlineKeyboard[0]) != 2 {
+		t.Fatalf("после Ack должно остаться две кнопки, получено %+v", left)
+	}
+}
+
+func TestTelegramSender_LiveUpdates(t *testing.T) {
+	var mu sync.Mutex
+	var edits []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/editMessageText") {
+			edits = append(edits, body)
+			w.Write([]byte(`{"ok":true,"result":true}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":77,"chat":{"id":-100}}}`))
+	}))
+	defer srv.Close()
+
+	tg, _ := NewTelegramSender(config.TelegramConfig{BotToken: "live-token", ChatID: "@alerts", LiveUpdates: true, Buttons: true})
+	tg.baseURL = srv.URL
+
+	entry := &log_processing.LogEntry{Level: "ERROR", Fingerprint: "live-fp"}
+	if err := tg.Send(context.Background(), Message{Text: "<b>boom</b>", Entry: entry}); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+
+	// бот принял алерт кнопкой: отметка и оставшиеся кнопки должны пережить обновление счётчика
+	MarkLiveMessage(-100, 77, "✅ Принял @dev", AlertKeyboard(*entry).Without(CallbackAck))
+
+	repeat := Message{Entry: entry, Event: EventRepeat, Repeat: &Repeat{Count: 3, LastSeen: time.Date(2026, 3, 1, 14, 5, 0, 0, time.Local)}}
+	if err := tg.Send(context.Background(), repeat); err != nil {
+		t.Fatalf("ошибка обновления: %v", err)
+	}
+
+	if len(edits) != 1 {
+		t.Fatalf("ожидается одно редактирование, получено %d", len(edits))
+	}
+	e := edits[0]
+	if e["message_id"].(float64) != 77 || e["chat_id"].(float64) != -100 {
+		t.Fatalf("редактироваться должно отправленное сообщение, получено %v", e)
+	}
+	want := "<b>boom</b>\n\n🔁 <b>Повторялось 3 раза</b>, последний раз в 14:05\n\n✅ Принял @dev"
+	if e["text"] != want {
+		t.Fatalf("ожидается %q, получено %q", want, e["text"])
+	}
+	buttons := e["reply_markup"].(map[string]any)["inline_keyboard"].([]any)[0].([]any)
+	if len(buttons) != 2 {
+		t.Fatalf("ожидаются кнопки без «Принял», получено %v", buttons)
+	}
+
+	// та же ошибка с другим временем: без group_by Fingerprint другой, но редактируется то же сообщение
+	first := &log_processing.LogEntry{Level: "ERROR", Message: "db timeout", Raw: "2026-03-01T12:00:00Z [ERROR] db timeout"}
+	first.Fingerprint = protect_from_duplicates.Fingerprint(first.Raw)
+	if err := tg.Send(context.Background(), Message{Text: "db timeout", Entry: first}); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+	later := &log_processing.LogEntry{Level: "ERROR", Message: "db timeout", Raw: "2026-03-01T12:00:07Z [ERROR] db timeout"}
+	later.Fingerprint = protect_from_duplicates.Fingerprint(later.Raw)
+	if err := tg.Send(context.Background(), Message{Entry: later, Event: EventRepeat, Repeat: &Repeat{Count: 2}}); err != nil {
+		t.Fatalf("ошибка обновления: %v", err)
+	}
+	if len(edits) != 2 || !strings.HasPrefix(edits[1]["text"].(string), "db timeout\n\n🔁") {
+		t.Fatalf("повтор с другим временем должен отредактировать ровно одно сообщение, получено %d", len(edits))
+	}
+
+	// повтор алерта, сообщение которого неизвестно, молча пропускается
+	other := Message{Entry: &log_processing.LogEntry{Fingerprint: "unknown"}, Event: EventRepeat, Repeat: &Repeat{Count: 2}}
+	if err := tg.Send(context.Background(), other); err != nil || len(edits) != 2 {
+		t.Fatalf("неизвестный алерт не должен редактироваться: %v", err)
+	}
+}
+
+func TestTimes(t *testing.T) {
+	for n, want := range map[int]string{1: "раз", 2: "раза", 4: "раза", 5: "раз", 12: "раз", 22: "раза", 111: "раз"} {
+		if got := times(n); got != want {
+			t.Fatalf("%d: ожидается %q, получено %q", n, want, got)
+		}
+	}
+}
+
+func TestTelegramDirectSender_Recipients(t *testing.T) {
+	fake := &fakeTelegram{failChat: "2"}
+	tg := NewTelegramDirectSender("token")
+	tg.baseURL = fake.server(t).URL
+
+	msg := Message{Text: "boom", Entry: &log_processing.LogEntry{Level: "ERROR"}, Recipients: []string{"1", "2"}}
+	if err := tg.Send(context.Background(), msg); err == nil {
+		t.Fatal("ожидается временная ошибка для подписчика 2")
+	}
+	fake.failChat = ""
+	if err := tg.Send(context.Background(), msg); err != nil {
+		t.Fatalf("повтор: %v", err)
+	}
+
+	if len(fake.requests) != 2 || fake.requests[0].ChatID != "1" || fake.requests[1].ChatID != "2" {
+		t.Fatalf("каждый подписчик должен получить сообщение один раз, получено %+v", fake.requests)
+	}
+}
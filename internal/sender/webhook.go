@@ -0,0 +1,167 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultWebhookTemplate тело по умолчанию: вся запись и отформатированный текст
+const defaultWebhookTemplate = `{"text":{{json .Text}},"entry":{{json .LogEntry}}}`
+
+// WebhookSender отправляет алерт HTTP-запросом с телом из шаблона
+type WebhookSender struct {
+	url             string
+	method          string
+	headers         map[string]string
+	body            *template.Template
+	secret          []byte
+	signatureHeader string
+	successCodes    map[int]bool // пусто = любой 2xx
+	client          *http.Client
+}
+
+// webhookData данные шаблона: поля LogEntry доступны напрямую (.Message, .Rule, .Fields.order_id),
+// .Text - сообщение, отформатированное formatter'ом получателя
+type webhookData struct {
+	log_processing.LogEntry
+	Text string
+}
+
+// templateFuncs функции, доступные в шаблонах тел запросов
+var templateFuncs = template.FuncMap{
+	// json кодирует значение в JSON, для строк получается строка в кавычках с экранированием
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func NewWebhookSender(cfg config.WebhookConfig) (*WebhookSender, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("не задан url вебхука")
+	}
+
+	src := cfg.BodyTemplate
+	if src == "" {
+		src = defaultWebhookTemplate
+	}
+	tmpl, err := template.New("body").Funcs(templateFuncs).Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора body_template: %w", err)
+	}
+
+	s := &WebhookSender{
+		url:             cfg.URL,
+		method:          strings.ToUpper(cfg.Method),
+		headers:         cfg.Headers,
+		body:            tmpl,
+		signatureHeader: cfg.SignatureHeader,
+		successCodes:    make(map[int]bool),
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}
+	if s.method == "" {
+		s.method = http.MethodPost
+	}
+	if cfg.HMACSecret != "" {
+		s.secret = []byte(cfg.HMACSecret)
+		if s.signatureHeader == "" {
+			s.signatureHeader = "X-Signature-256"
+		}
+	}
+	for _, code := range cfg.SuccessCodes {
+		s.successCodes[code] = true
+	}
+	if cfg.TimeoutMS > 0 {
+		s.client.Timeout = time.Duration(cfg.TimeoutMS) * time.Millisecond
+	}
+
+	return s, nil
+}
+
+func (s *WebhookSender) Send(ctx context.Context, msg Message) error {
+	data := webhookData{Text: msg.Text}
+	if msg.Entry != nil {
+		data.LogEntry = *msg.Entry
+	}
+
+	var body bytes.Buffer
+	if err := s.body.Execute(&body, data); err != nil {
+		return fmt.Errorf("ошибка шаблона тела вебхука: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, s.method, s.url, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса вебхука: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	if s.secret != nil {
+		req.Header.Set(s.signatureHeader, "sha256="+Sign(s.secret, body.Bytes()))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось выполнить запрос вебхука: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if s.success(resp.StatusCode) {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("ошибка вебхука: %w", &APIError{
+		StatusCode:  resp.StatusCode,
+		Description: strings.TrimSpace(string(snippet)),
+		RetryAfter:  parseRetryAfter(resp.Header.Get("Retry-After")),
+	})
+}
+
+func (s *WebhookSender) success(code int) bool {
+	if len(s.successCodes) == 0 {
+		return code >= 200 && code < 300
+	}
+	return s.successCodes[code]
+}
+
+// Sign HMAC-SHA256 тела в hex; получатель проверяет подпись тем же секретом
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseRetryAfter заголовок Retry-After: число секунд или HTTP-дата
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
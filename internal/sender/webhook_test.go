@@ -0,0 +1,92 @@
+package sender
+
+import (
+	"Bug_tracking_bot/internal/config"
+	"Bug_tracking_bot/internal/log_processing"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSender_TemplateAndSignature(t *testing.T) {
+	var gotBody []byte
+	var gotSig, gotAuth, gotMethod string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get("X-Hub-Signature")
+		gotAuth = r.Header.Get("Authorization")
+		gotMethod = r.Method
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	wh, err := NewWebhookSender(config.WebhookConfig{
+		URL:             srv.URL,
+		Method:          "put",
+		Headers:         map[string]string{"Authorization": "Bearer secret-token"},
+		BodyTemplate:    `{"summary":{{json .Message}},"severity":{{json (lower .Level)}},"rule":{{json .Rule}},"order":{{json .Fields.order_id}}}`,
+		HMACSecret:      "s3cr3t",
+		SignatureHeader: "X-Hub-Signature",
+		SuccessCodes:    []int{202},
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	entry := &log_processing.LogEntry{
+		Timestamp: time.Now(),
+		Level:     "ERROR",
+		Message:   `order 123 "failed"`,
+		Rule:      "payments",
+		Fields:    map[string]string{"order_id": "123"},
+	}
+	if err := wh.Send(context.Background(), Message{Text: "text", Entry: entry}); err != nil {
+		t.Fatalf("ожидается успешная отправка, получено: %v", err)
+	}
+
+	var body map[string]string
+	if err := json.Unmarshal(gotBody, &body); err != nil {
+		t.Fatalf("тело должно быть валидным JSON: %v\n%s", err, gotBody)
+	}
+	if body["summary"] != entry.Message || body["severity"] != "error" || body["rule"] != "payments" || body["order"] != "123" {
+		t.Fatalf("неверное тело: %v", body)
+	}
+	if gotSig != "sha256="+Sign([]byte("s3cr3t"), gotBody) {
+		t.Fatalf("неверная подпись: %s", gotSig)
+	}
+	if gotAuth != "Bearer secret-token" || gotMethod != http.MethodPut {
+		t.Fatalf("неверные заголовки или метод: %q %q", gotAuth, gotMethod)
+	}
+}
+
+func TestWebhookSender_StatusErrors(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	wh, err := NewWebhookSender(config.WebhookConfig{URL: srv.URL, SuccessCodes: []int{201}})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	// 200 не входит в success_codes
+	err = wh.Send(context.Background(), Message{Text: "x"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || IsRetryable(err) {
+		t.Fatalf("ожидается неповторяемая APIError со статусом 200, получено %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	err = wh.Send(context.Background(), Message{Text: "x"})
+	if !IsRetryable(err) || retryAfter(err) != 3*time.Second {
+		t.Fatalf("503 с Retry-After должна повторяться через 3s, получено %v", err)
+	}
+}
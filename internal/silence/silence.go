@@ -0,0 +1,311 @@
+package silence
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Silence правило временного подавления алертов (аналог silence в Alertmanager).
+// Пустые поля-матчеры означают "любое значение", заполненные проверяются все вместе (И).
+type Silence struct {
+	ID          string            `json:"id"`
+	Rule        string            `json:"rule,omitempty"`
+	Level       string            `json:"level,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	CreatedBy string    `json:"created_by"`
+	Comment   string    `json:"comment,omitempty"`
+
+	// Статистика подавленных алертов для итоговой сводки
+	Suppressed int            `json:"suppressed"`
+	ByRule     map[string]int `json:"by_rule,omitempty"`
+}
+
+// Active действует ли silence в момент now
+func (s Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// Matches подходит ли запись под матчеры silence (время не проверяется)
+func (s Silence) Matches(entry log_processing.LogEntry) bool {
+	if s.Rule != "" && s.Rule != entry.Rule {
+		return false
+	}
+	if s.Level != "" && !strings.EqualFold(s.Level, entry.Level) {
+		return false
+	}
+	// fingerprint - ключ дедупликации записи или её стабильный ключ (так заглушает кнопка Mute)
+	if s.Fingerprint != "" && s.Fingerprint != entry.Fingerprint && s.Fingerprint != protect_from_duplicates.StableKey(entry) {
+		return false
+	}
+	for k, v := range s.Fields {
+		if got, ok := entry.Fields[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate проверяет, что silence не подавляет вообще всё и имеет корректный интервал
+func (s Silence) Validate() error {
+	if s.Rule == "" && s.Level == "" && s.Fingerprint == "" && len(s.Fields) == 0 {
+		return fmt.Errorf("silence должен содержать хотя бы один матчер (rule, level, fingerprint или field)")
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("время окончания silence должно быть позже начала")
+	}
+	if strings.TrimSpace(s.CreatedBy) == "" {
+		return fmt.Errorf("у silence должен быть автор")
+	}
+	return nil
+}
+
+// Store хранит silences в JSON-файле, чтобы они переживали перезапуск бота.
+// Файл может редактироваться снаружи (команда silence), поэтому перед каждым чтением
+// и записью проверяем его ModTime и перечитываем при изменении.
+type Store struct {
+	mu       sync.Mutex
+	path     string
+	mtime    time.Time
+	silences []Silence
+	dirty    bool // есть несохранённые счётчики подавленных алертов
+}
+
+// Open открывает хранилище; отсутствующий файл означает пустой список silences
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Refresh перечитывает файл, если он изменился с прошлого чтения
+func (s *Store) Refresh() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshLocked()
+}
+
+func (s *Store) refreshLocked() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка получения данных о файле silences: %w", err)
+	}
+	if !info.ModTime().After(s.mtime) {
+		return nil
+	}
+
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения файла silences: %w", err)
+	}
+
+	var loaded []Silence
+	if len(strings.TrimSpace(string(b))) > 0 {
+		if err := json.Unmarshal(b, &loaded); err != nil {
+			return fmt.Errorf("ошибка декодирования файла silences: %w", err)
+		}
+	}
+
+	// Счётчики подавленных алертов ведёт только бот, поэтому сохраняем их из памяти
+	known := make(map[string]Silence, len(s.silences))
+	for _, sl := range s.silences {
+		known[sl.ID] = sl
+	}
+	for i := range loaded {
+		if old, ok := known[loaded[i].ID]; ok && old.Suppressed > loaded[i].Suppressed {
+			loaded[i].Suppressed = old.Suppressed
+			loaded[i].ByRule = old.ByRule
+		}
+	}
+
+	s.silences = loaded
+	s.mtime = info.ModTime()
+	return nil
+}
+
+// saveLocked пишет файл атомарно через временный файл
+func (s *Store) saveLocked() error {
+	if err := s.refreshLocked(); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(s.silences, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования silences: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла silences: %w", err)
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи silences: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи silences: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сохранения файла silences: %w", err)
+	}
+
+	if info, err := os.Stat(s.path); err == nil {
+		s.mtime = info.ModTime()
+	}
+	s.dirty = false
+	return nil
+}
+
+// Add сохраняет новый silence, присваивая ему ID
+func (s *Store) Add(sl Silence) (Silence, error) {
+	if err := sl.Validate(); err != nil {
+		return Silence{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refreshLocked(); err != nil {
+		return Silence{}, err
+	}
+
+	sl.ID = newID()
+	sl.Suppressed = 0
+	sl.ByRule = nil
+	s.silences = append(s.silences, sl)
+
+	if err := s.saveLocked(); err != nil {
+		return Silence{}, err
+	}
+	return sl, nil
+}
+
+// Expire досрочно завершает silence; сводка будет отправлена при следующем вызове Expired
+func (s *Store) Expire(id string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refreshLocked(); err != nil {
+		return err
+	}
+
+	for i := range s.silences {
+		if s.silences[i].ID != id {
+			continue
+		}
+		if s.silences[i].EndsAt.After(now) {
+			s.silences[i].EndsAt = now
+		}
+		if s.silences[i].StartsAt.After(now) {
+			s.silences[i].StartsAt = now
+		}
+		return s.saveLocked()
+	}
+	return fmt.Errorf("silence %s не найден", id)
+}
+
+// List возвращает копию всех silences, отсортированных по времени окончания
+func (s *Store) List() []Silence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Silence, len(s.silences))
+	copy(out, s.silences)
+	sort.Slice(out, func(i, j int) bool { return out[i].EndsAt.Before(out[j].EndsAt) })
+	return out
+}
+
+// Check возвращает активный silence, под который попадает запись, и учитывает её в статистике
+func (s *Store) Check(entry log_processing.LogEntry, now time.Time) (Silence, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.silences {
+		sl := &s.silences[i]
+		if !sl.Active(now) || !sl.Matches(entry) {
+			continue
+		}
+		sl.Suppressed++
+		if sl.ByRule == nil {
+			sl.ByRule = make(map[string]int)
+		}
+		sl.ByRule[entry.Rule]++
+		s.dirty = true
+		return *sl, true
+	}
+	return Silence{}, false
+}
+
+// Flush сохраняет накопленные счётчики, если они изменились
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// Expired удаляет из хранилища закончившиеся silences и возвращает их для итоговой сводки
+func (s *Store) Expired(now time.Time) ([]Silence, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refreshLocked(); err != nil {
+		return nil, err
+	}
+
+	var expired []Silence
+	kept := s.silences[:0:0]
+	for _, sl := range s.silences {
+		if !now.Before(sl.EndsAt) {
+			expired = append(expired, sl)
+			continue
+		}
+		kept = append(kept, sl)
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	s.silences = kept
+	if err := s.saveLocked(); err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+func newID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package silence
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"Bug_tracking_bot/internal/log_processing/parser"
+	"Bug_tracking_bot/internal/log_processing/protect_from_duplicates"
+	"Bug_tracking_bot/internal/sender"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSilence_Matches(t *testing.T) {
+	s := Silence{Level: "error", Fields: map[string]string{"order_id": "123"}}
+
+	entry := log_processing.LogEntry{Level: "ERROR", Fields: map[string]string{"order_id": "123"}}
+	if !s.Matches(entry) {
+		t.Fatal("ожидается совпадение по уровню и полю")
+	}
+
+	entry.Fields = map[string]string{"order_id": "456"}
+	if s.Matches(entry) {
+		t.Fatal("ожидается отсутствие совпадения для другого значения поля")
+	}
+}
+
+func TestSilence_MuteKeyMatchesRepeats(t *testing.T) {
+	parse := func(line string) log_processing.LogEntry {
+		entry, err := parser.ParseLine(line)
+		if err != nil {
+			t.Fatalf("ошибка разбора строки: %v", err)
+		}
+		entry.Rule = "payments"
+		entry.Fingerprint = protect_from_duplicates.Key(entry, nil)
+		return entry
+	}
+	first := parse("2026-03-01T12:00:00Z [ERROR] order 123 failed")
+	repeat := parse("2026-03-01T12:05:00Z [ERROR] order 456 failed")
+	other := parse("2026-03-01T12:06:00Z [ERROR] payment gateway timeout")
+
+	// ключ берётся из кнопки Mute под первым алертом
+	_, key, _ := sender.ParseCallback(sender.AlertKeyboard(first).InlineKeyboard[0][1].CallbackData)
+	s := Silence{Fingerprint: key}
+	if !s.Matches(repeat) {
+		t.Fatal("Mute должен глушить повтор той же ошибки с другим временем и номером")
+	}
+	if s.Matches(other) {
+		t.Fatal("Mute не должен глушить другую ошибку того же правила")
+	}
+}
+
+func TestSilence_ValidateRequiresMatcher(t *testing.T) {
+	now := time.Now()
+	s := Silence{StartsAt: now, EndsAt: now.Add(time.Hour), CreatedBy: "ops"}
+	if err := s.Validate(); err == nil {
+		t.Fatal("ожидается ошибка для silence без матчеров")
+	}
+}
+
+func TestStore_CheckPersistAndExpire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "silences.json")
+	now := time.Now()
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	added, err := store.Add(Silence{
+		Fingerprint: "a4c823845a27",
+		StartsAt:    now.Add(-time.Minute),
+		EndsAt:      now.Add(time.Hour),
+		CreatedBy:   "ops",
+		Comment:     "деплой",
+	})
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	entry := log_processing.LogEntry{Rule: "^Error", Fingerprint: "a4c823845a27"}
+	if _, ok := store.Check(entry, now); !ok {
+		t.Fatal("ожидается, что запись подавлена")
+	}
+	if _, ok := store.Check(log_processing.LogEntry{Fingerprint: "other"}, now); ok {
+		t.Fatal("другой fingerprint не должен подавляться")
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("ошибка сохранения: %v", err)
+	}
+
+	// Новое хранилище должно прочитать silence и счётчики из файла
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	if err := reopened.Expire(added.ID, now); err != nil {
+		t.Fatalf("ошибка завершения silence: %v", err)
+	}
+
+	expired, err := reopened.Expired(now)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Suppressed != 1 || expired[0].ByRule["^Error"] != 1 {
+		t.Fatalf("ожидается один закончившийся silence с одним подавленным алертом, получено %+v", expired)
+	}
+	if len(reopened.List()) != 0 {
+		t.Fatal("закончившийся silence должен быть удалён из файла")
+	}
+}
@@ -0,0 +1,174 @@
+package subscription
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Подписки пользователей на алерты по правилу, уровню или источнику (команды /subscribe и /unsubscribe).
+// Подходящие алерты дополнительно приходят подписчику в личные сообщения.
+
+const (
+	KindRule   = "rule"
+	KindLevel  = "level"
+	KindSource = "source"
+)
+
+// Subscription подписка одного пользователя
+type Subscription struct {
+	UserID    int64     `json:"user_id"`
+	User      string    `json:"user,omitempty"` // @username для списка и логов
+	Kind      string    `json:"kind"`           // rule | level | source
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Matches подходит ли запись под подписку; source сравнивается и с полным путём, и с именем файла
+func (s Subscription) Matches(entry log_processing.LogEntry) bool {
+	switch s.Kind {
+	case KindRule:
+		return entry.Rule == s.Value
+	case KindLevel:
+		return strings.EqualFold(entry.Level, s.Value)
+	case KindSource:
+		return entry.Source != "" && (entry.Source == s.Value || filepath.Base(entry.Source) == s.Value)
+	}
+	return false
+}
+
+// Store хранит подписки в JSON-файле, чтобы они переживали перезапуск бота
+type Store struct {
+	mu   sync.Mutex
+	path string
+	subs []Subscription
+}
+
+// Open открывает хранилище; отсутствующий файл означает, что подписок нет
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла подписок: %w", err)
+	}
+	if len(strings.TrimSpace(string(b))) > 0 {
+		if err := json.Unmarshal(b, &s.subs); err != nil {
+			return nil, fmt.Errorf("ошибка декодирования файла подписок: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Add добавляет подписку; false - такая подписка уже есть
+func (s *Store) Add(sub Subscription) (bool, error) {
+	if sub.Kind != KindRule && sub.Kind != KindLevel && sub.Kind != KindSource {
+		return false, fmt.Errorf("неизвестный тип подписки %q, ожидается rule, level или source", sub.Kind)
+	}
+	if strings.TrimSpace(sub.Value) == "" {
+		return false, fmt.Errorf("не указано, на что подписаться")
+	}
+	if sub.Kind == KindLevel {
+		sub.Value = strings.ToUpper(sub.Value)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, old := range s.subs {
+		if old.UserID == sub.UserID && old.Kind == sub.Kind && old.Value == sub.Value {
+			return false, nil
+		}
+	}
+	s.subs = append(s.subs, sub)
+	return true, s.saveLocked()
+}
+
+// Remove удаляет подписки пользователя; пустой kind - все подписки. Возвращает, сколько удалено.
+func (s *Store) Remove(userID int64, kind, value string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.subs)
+	s.subs = slices.DeleteFunc(s.subs, func(sub Subscription) bool {
+		if sub.UserID != userID {
+			return false
+		}
+		return kind == "" || (sub.Kind == kind && strings.EqualFold(sub.Value, value))
+	})
+	removed := before - len(s.subs)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.saveLocked()
+}
+
+// List подписки пользователя
+func (s *Store) List(userID int64) []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Subscription
+	for _, sub := range s.subs {
+		if sub.UserID == userID {
+			out = append(out, sub)
+		}
+	}
+	return out
+}
+
+// Match пользователи из allowed, подписанные на запись, без повторов.
+// Подписки пользователя, убранного из allowed_users, остаются в файле, но не срабатывают, пока его не вернут.
+func (s *Store) Match(entry log_processing.LogEntry, allowed []int64) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []int64
+	for _, sub := range s.subs {
+		if sub.Matches(entry) && slices.Contains(allowed, sub.UserID) && !slices.Contains(users, sub.UserID) {
+			users = append(users, sub.UserID)
+		}
+	}
+	return users
+}
+
+// saveLocked пишет файл атомарно через временный файл
+func (s *Store) saveLocked() error {
+	b, err := json.MarshalIndent(s.subs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка кодирования подписок: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла подписок: %w", err)
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи подписок: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка записи подписок: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("ошибка сохранения файла подписок: %w", err)
+	}
+	return nil
+}
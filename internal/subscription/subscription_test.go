@@ -0,0 +1,58 @@
+package subscription
+
+import (
+	"Bug_tracking_bot/internal/log_processing"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_AddMatchRemovePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+	st, err := Open(path)
+	if err != nil {
+		t.Fatalf("ожидается без ошибок, получено: %v", err)
+	}
+
+	for _, sub := range []Subscription{
+		{UserID: 1, Kind: KindRule, Value: "payments"},
+		{UserID: 1, Kind: KindLevel, Value: "error"},
+		{UserID: 2, Kind: KindSource, Value: "app.log"},
+	} {
+		if added, err := st.Add(sub); err != nil || !added {
+			t.Fatalf("ожидается добавление %+v, получено %v %v", sub, added, err)
+		}
+	}
+	if added, _ := st.Add(Subscription{UserID: 1, Kind: KindRule, Value: "payments"}); added {
+		t.Fatal("повторная подписка не должна добавляться")
+	}
+	if _, err := st.Add(Subscription{UserID: 1, Kind: "host", Value: "x"}); err == nil {
+		t.Fatal("ожидается ошибка для неизвестного типа подписки")
+	}
+
+	entry := log_processing.LogEntry{Level: "ERROR", Rule: "payments", Source: "/var/log/app.log"}
+	if got := st.Match(entry, []int64{1, 2}); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("ожидаются пользователи 1 и 2 без повторов, получено %v", got)
+	}
+
+	if got := st.Match(entry, []int64{2}); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("пользователь 1 убран из allowed_users и не должен получать алерты, получено %v", got)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("ошибка повторного открытия: %v", err)
+	}
+	if n := len(reopened.List(1)); n != 2 {
+		t.Fatalf("подписки должны пережить перезапуск, получено %d", n)
+	}
+
+	if n, _ := reopened.Remove(1, KindLevel, "ERROR"); n != 1 {
+		t.Fatalf("ожидается удаление одной подписки, удалено %d", n)
+	}
+	if n, _ := reopened.Remove(1, "", ""); n != 1 {
+		t.Fatalf("ожидается удаление оставшейся подписки, удалено %d", n)
+	}
+	if got := reopened.Match(entry, []int64{1, 2}); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("после отписки остаётся только пользователь 2, получено %v", got)
+	}
+}